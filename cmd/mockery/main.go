@@ -0,0 +1,278 @@
+// Command mockery serves mock endpoints loaded from wiremock mapping directories, mockery config files and OpenAPI 3
+// documents, see package config for the format of the config files.
+//
+// Usage:
+//
+//    mockery [flags]
+//
+// The flags are:
+//
+//    -port 8080                 the port to listen on.
+//    -wiremock dir              a directory holding wiremock "mappings" and "__files", may be repeated.
+//    -strict                    fails, listing every problem, if a wiremock mapping has fields that are unknown or
+//                               not supported, see wiremock.LoadWireMock.
+//    -config file               a YAML or JSON mockery config file, may be repeated.
+//    -openapi file              an OpenAPI 3 document, in YAML or JSON, whose operations respond with the examples it
+//                               gives or data generated from their schemas, may be repeated, see package openapi.
+//    -tls-cert file             the certificate to serve HTTPS with, requires -tls-key.
+//    -tls-key file              the private key of the certificate.
+//    -tls-auto file             serves HTTPS with a certificate issued by a CA generated at startup, the CA's
+//                               certificate is written to the file given so that clients can trust it.
+//    -tls-client-ca file        requires HTTPS clients to present a certificate issued by a CA in the PEM file given.
+//    -admin /__admin            serves the admin API under the prefix given, disabled by default.
+//    -admin-files dir           the directory files named by mappings posted to the admin API are looked for in, the
+//                               __files directory of the first -wiremock directory by default.
+//    -watch 1s                  reloads the -wiremock directories when their files change, checking at the
+//                               interval given, disabled by default.  With -strict a reload that finds a problem
+//                               keeps the endpoints as they were.
+//    -diagnose                  responds to unmatched requests with the closest endpoints and the conditions they
+//                               failed, see httpmock.DiagnoseUnmatched.
+//    -metrics /metrics          serves Prometheus metrics about the requests served at the path given, disabled by
+//                               default, see httpmock.Metrics.
+//    -shutdown-timeout 10s      how long in-flight requests are given to complete on SIGINT or SIGTERM.
+//
+// e.g.
+//
+//    mockery -port 9090 -wiremock ./stubs -config ./orders.yaml -admin /__admin
+//
+package main
+
+import (
+	"github.com/bluesoftdev/mockery/httpmock"
+	"github.com/bluesoftdev/mockery/httpmock/config"
+	"github.com/bluesoftdev/mockery/httpmock/openapi"
+	"github.com/bluesoftdev/mockery/httpmock/wiremock"
+
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type options struct {
+	port            int
+	wireMockDirs    stringList
+	strict          bool
+	configFiles     stringList
+	openAPIFiles    stringList
+	tlsCert         string
+	tlsKey          string
+	tlsAuto         string
+	tlsClientCA     string
+	adminPrefix     string
+	adminFiles      string
+	watch           time.Duration
+	diagnose        bool
+	metricsPath     string
+	shutdownTimeout time.Duration
+}
+
+func parseOptions(args []string) (*options, error) {
+	opts := &options{}
+	flags := flag.NewFlagSet("mockery", flag.ContinueOnError)
+	flags.IntVar(&opts.port, "port", 8080, "the port to listen on")
+	flags.Var(&opts.wireMockDirs, "wiremock", "a directory holding wiremock mappings and __files, may be repeated")
+	flags.BoolVar(&opts.strict, "strict", false, "fails if a wiremock mapping has unknown or unsupported fields")
+	flags.Var(&opts.configFiles, "config", "a YAML or JSON mockery config file, may be repeated")
+	flags.Var(&opts.openAPIFiles, "openapi", "an OpenAPI 3 document to mock, may be repeated")
+	flags.StringVar(&opts.tlsCert, "tls-cert", "", "the certificate to serve HTTPS with")
+	flags.StringVar(&opts.tlsKey, "tls-key", "", "the private key of the certificate")
+	flags.StringVar(&opts.tlsAuto, "tls-auto", "", "serves HTTPS with generated certificates, writing the CA to the file given")
+	flags.StringVar(&opts.tlsClientCA, "tls-client-ca", "", "requires client certificates issued by a CA in the file given")
+	flags.StringVar(&opts.adminPrefix, "admin", "", "serves the admin API under the prefix given, e.g. /__admin")
+	flags.StringVar(&opts.adminFiles, "admin-files", "", "the directory files named by admin API mappings are looked for in")
+	flags.DurationVar(&opts.watch, "watch", 0, "reloads the -wiremock directories when their files change")
+	flags.BoolVar(&opts.diagnose, "diagnose", false, "explains why unmatched requests did not match any endpoint")
+	flags.StringVar(&opts.metricsPath, "metrics", "", "serves Prometheus metrics at the path given, e.g. /metrics")
+	flags.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", 10*time.Second,
+		"how long in-flight requests are given to complete on shutdown")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if len(opts.wireMockDirs) == 0 && len(opts.configFiles) == 0 && len(opts.openAPIFiles) == 0 {
+		return nil, errors.New("at least one -wiremock directory, -config file or -openapi document is required")
+	}
+	if (opts.tlsCert == "") != (opts.tlsKey == "") {
+		return nil, errors.New("-tls-cert and -tls-key must be given together")
+	}
+	if opts.tlsAuto != "" && opts.tlsCert != "" {
+		return nil, errors.New("-tls-auto can't be given with -tls-cert")
+	}
+	if opts.tlsClientCA != "" && opts.tlsAuto == "" && opts.tlsCert == "" {
+		return nil, errors.New("-tls-client-ca requires -tls-cert or -tls-auto")
+	}
+	if opts.adminFiles == "" {
+		opts.adminFiles = "."
+		if len(opts.wireMockDirs) > 0 {
+			opts.adminFiles = filepath.Join(opts.wireMockDirs[0], "__files")
+		}
+	}
+	return opts, nil
+}
+
+// newHandler builds the mock described by the options, the errors found while loading the endpoints are returned.
+// If the -watch flag was given the wiremock directories are watched for the life of the process.
+func newHandler(opts *options) (http.Handler, error) {
+	var err error
+	stubs := make([][]wiremock.Stub, len(opts.wireMockDirs))
+	if opts.strict {
+		for i, dir := range opts.wireMockDirs {
+			if stubs[i], err = wiremock.LoadWireMock(dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	mock, err := httpmock.NewE(func(b *httpmock.Builder) {
+		if opts.diagnose {
+			b.DiagnoseUnmatched()
+		}
+		if opts.metricsPath != "" {
+			b.Metrics(opts.metricsPath)
+		}
+		for i, dir := range opts.wireMockDirs {
+			if opts.strict {
+				wiremock.AddStubs(b, stubs[i])
+			} else {
+				wiremock.AddWireMockEndpoints(b, dir)
+			}
+		}
+		for _, file := range opts.configFiles {
+			config.AddConfigEndpoints(b, file)
+		}
+		for _, file := range opts.openAPIFiles {
+			openapi.AddOpenAPIEndpoints(b, file)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.watch > 0 {
+		var reloadOptions []wiremock.ReloadOption
+		if opts.strict {
+			reloadOptions = append(reloadOptions, wiremock.StrictMappings)
+		}
+		for _, dir := range opts.wireMockDirs {
+			wiremock.WatchWireMockEndpoints(mock, dir, opts.watch, reloadOptions...)
+		}
+	}
+	if opts.adminPrefix != "" {
+		return wiremock.WithAdmin(mock, opts.adminPrefix, opts.adminFiles), nil
+	}
+	return mock, nil
+}
+
+// newTLSConfig returns the TLS configuration for the -tls-auto and -tls-client-ca options, or nil if neither was given.
+func newTLSConfig(opts *options) (*tls.Config, error) {
+	if opts.tlsAuto == "" && opts.tlsClientCA == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{}
+	if opts.tlsAuto != "" {
+		ca, err := httpmock.NewCertificateAuthority("Mockery CA")
+		if err != nil {
+			return nil, err
+		}
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if hostname, err := os.Hostname(); err == nil {
+			hosts = append(hosts, hostname)
+		}
+		certificate, err := ca.IssueCertificate("Mockery", hosts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(opts.tlsAuto, ca.PEM, 0644); err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if opts.tlsClientCA != "" {
+		data, err := ioutil.ReadFile(opts.tlsClientCA)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.tlsClientCA)
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// serve serves requests on the listener until a signal is received from stop, the requests in flight are then given
+// the shutdown timeout to complete.
+func serve(listener net.Listener, server *http.Server, opts *options, stop <-chan os.Signal) error {
+	served := make(chan error, 1)
+	go func() {
+		if opts.tlsCert != "" || server.TLSConfig != nil {
+			served <- server.ServeTLS(listener, opts.tlsCert, opts.tlsKey)
+		} else {
+			served <- server.Serve(listener)
+		}
+	}()
+	select {
+	case err := <-served:
+		return err
+	case sig := <-stop:
+		log.Printf("Received %s, shutting down", sig)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+func main() {
+	opts, err := parseOptions(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	handler, err := newHandler(opts)
+	if err != nil {
+		log.Fatalf("Error loading endpoints:\n%s", err.Error())
+	}
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		log.Fatalf("Error configuring TLS: %s", err.Error())
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", opts.port))
+	if err != nil {
+		log.Fatal(err)
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	log.Printf("Mockery listening on %s", listener.Addr())
+	server := &http.Server{Handler: handler, TLSConfig: tlsConfig}
+	if err := serve(listener, server, opts, stop); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
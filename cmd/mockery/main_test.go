@@ -0,0 +1,171 @@
+package main
+
+import (
+	"github.com/bluesoftdev/mockery/httpmock"
+
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseOptions(t *testing.T) {
+	opts, err := parseOptions([]string{"-port", "9090", "-wiremock", "a", "-wiremock", "b", "-config", "c.yaml",
+		"-admin", "/__admin", "-diagnose", "-metrics", "/metrics"})
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, opts.port)
+	assert.Equal(t, stringList{"a", "b"}, opts.wireMockDirs)
+	assert.Equal(t, stringList{"c.yaml"}, opts.configFiles)
+	assert.Equal(t, "/__admin", opts.adminPrefix)
+	assert.Equal(t, "a/__files", opts.adminFiles)
+	assert.Equal(t, 10*time.Second, opts.shutdownTimeout)
+	assert.True(t, opts.diagnose)
+	assert.Equal(t, "/metrics", opts.metricsPath)
+
+	_, err = parseOptions([]string{})
+	assert.Error(t, err)
+	_, err = parseOptions([]string{"-config", "c.yaml", "-tls-cert", "cert.pem"})
+	assert.Error(t, err)
+	_, err = parseOptions([]string{"-config", "c.yaml", "extra"})
+	assert.Error(t, err)
+	_, err = parseOptions([]string{"-config", "c.yaml", "-tls-auto", "ca.pem", "-tls-cert", "c", "-tls-key", "k"})
+	assert.Error(t, err)
+	_, err = parseOptions([]string{"-config", "c.yaml", "-tls-client-ca", "ca.pem"})
+	assert.Error(t, err)
+}
+
+func TestNewHandlerLoadError(t *testing.T) {
+	_, err := newHandler(&options{configFiles: stringList{"does-not-exist.yaml"}})
+	assert.Error(t, err)
+}
+
+func TestNewHandlerOpenAPI(t *testing.T) {
+	opts, err := parseOptions([]string{"-openapi", "../../httpmock/openapi/testdata/petstore.yaml"})
+	if assert.NoError(t, err) {
+		handler, err := newHandler(opts)
+		if assert.NoError(t, err) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/pets/mine", nil))
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, "Fido", w.Body.String())
+		}
+	}
+	_, err = newHandler(&options{openAPIFiles: stringList{"does-not-exist.yaml"}})
+	assert.Error(t, err)
+}
+
+func TestNewHandlerStrict(t *testing.T) {
+	_, err := newHandler(&options{wireMockDirs: stringList{"../../httpmock/wiremock/testdata/strict"}, strict: true})
+	assert.Error(t, err)
+	_, err = newHandler(&options{wireMockDirs: stringList{"../../httpmock/wiremock/testdata/valid"}, strict: true})
+	assert.NoError(t, err)
+}
+
+func TestServe(t *testing.T) {
+	opts, err := parseOptions([]string{"-wiremock", "../../httpmock/wiremock",
+		"-config", "../../httpmock/config/testdata/orders.yaml", "-admin", "/__admin", "-shutdown-timeout", "1s"})
+	assert.NoError(t, err)
+	handler, err := newHandler(opts)
+	assert.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(listener, &http.Server{Handler: handler}, opts, stop)
+	}()
+
+	baseURL := "http://" + listener.Addr().String()
+	for url, expected := range map[string]string{
+		"/testmapping":       "default test mapping",
+		"/orders/12/invoice": "INVOICE\n",
+	} {
+		response, err := http.Get(baseURL + url)
+		if assert.NoError(t, err) {
+			body, _ := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			assert.Equal(t, expected, string(body))
+		}
+	}
+	response, err := http.Get(baseURL + "/__admin/mappings")
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, 200, response.StatusCode)
+	}
+
+	stop <- syscall.SIGTERM
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestServeTLSAuto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	clientCA, err := httpmock.NewCertificateAuthority("Client CA")
+	if !assert.NoError(t, err) {
+		return
+	}
+	clientCAFile := filepath.Join(dir, "client-ca.pem")
+	assert.NoError(t, ioutil.WriteFile(clientCAFile, clientCA.PEM, 0644))
+	caFile := filepath.Join(dir, "ca.pem")
+	opts, err := parseOptions([]string{"-config", "../../httpmock/config/testdata/orders.yaml", "-tls-auto", caFile,
+		"-tls-client-ca", clientCAFile, "-shutdown-timeout", "1s"})
+	assert.NoError(t, err)
+	handler, err := newHandler(opts)
+	assert.NoError(t, err)
+	tlsConfig, err := newTLSConfig(opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(listener, &http.Server{Handler: handler, TLSConfig: tlsConfig}, opts, stop)
+	}()
+
+	caPEM, err := ioutil.ReadFile(caFile)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	assert.True(t, roots.AppendCertsFromPEM(caPEM))
+	clientCertificate, err := clientCA.IssueCertificate("billing")
+	assert.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots,
+		Certificates: []tls.Certificate{clientCertificate}}}}
+	url := "https://" + listener.Addr().String() + "/orders/12/invoice"
+	response, err := client.Get(url)
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, "INVOICE\n", string(body))
+	}
+	withoutCertificate := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	_, err = withoutCertificate.Get(url)
+	assert.Error(t, err)
+
+	stop <- syscall.SIGTERM
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
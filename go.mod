@@ -8,4 +8,6 @@ require (
 	github.com/stretchr/testify v1.2.2
 	github.com/wcharczuk/go-chart v2.0.1+incompatible
 	golang.org/x/image v0.0.0-20180926015637-991ec62608f3 // indirect
+	gopkg.in/xmlpath.v2 v2.0.0-20150820204837-860cbeca3ebc
+	gopkg.in/yaml.v2 v2.4.0
 )
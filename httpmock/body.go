@@ -1,26 +1,394 @@
 package httpmock
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"github.com/bluesoftdev/go-http-matchers/extractor"
 	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"gopkg.in/xmlpath.v2"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// requestBody reads the body of the request and restores it so that it may be read again.
+func requestBody(request *http.Request) []byte {
+	if request.Body == nil {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// ExtractBody returns an Extractor that expects a *http.Request and extracts the body as a string.  The body of the
+// request is restored so that it may be read again.
+func ExtractBody() extractor.Extractor {
+	return extractor.ExtractorFunc(func(r interface{}) interface{} {
+		return string(requestBody(r.(*http.Request)))
+	})
+}
+
+// BodyEquals checks to see if the body of the request equals the value given.
+func BodyEquals(value string) predicate.Predicate {
+	return predicate.ExtractedValueAccepted(ExtractBody(), predicate.StringEquals(value))
+}
+
+// BodyContains checks to see if the body of the request contains the value given.
+func BodyContains(value string) predicate.Predicate {
+	return predicate.ExtractedValueAccepted(ExtractBody(), predicate.StringContains(value))
+}
+
+// BodyMatches checks to see if the body of the request matches the regular expression given in the 'pattern'
+// parameter.
+func BodyMatches(pattern *regexp.Regexp) predicate.Predicate {
+	return predicate.ExtractedValueAccepted(ExtractBody(), predicate.StringMatches(pattern))
+}
+
+// extractXPathString is like extractor.ExtractXPathString but restores the body of the request so that several
+// predicates may examine it.
+func extractXPathString(xpath string) extractor.Extractor {
+	path := xmlpath.MustCompile(xpath)
+	return extractor.ExtractorFunc(func(r interface{}) interface{} {
+		str := ""
+		root, err := xmlpath.Parse(bytes.NewReader(requestBody(r.(*http.Request))))
+		if err == nil {
+			str, _ = path.String(root)
+		}
+		return str
+	})
+}
+
 // BodyXPathEquals checks to see if the result of the xpath expression, matches the string supplied in the 'value'
 // parameter.
 func BodyXPathEquals(xpath, value string) predicate.Predicate {
-	return predicate.ExtractedValueAccepted(extractor.ExtractXPathString(xpath), predicate.StringEquals(value))
+	return predicate.ExtractedValueAccepted(extractXPathString(xpath), predicate.StringEquals(value))
 }
 
 // BodyXPathEqualsIgnoreCase similar to BodyXPathEquals but ignores case when comparing the strings.
 func BodyXPathEqualsIgnoreCase(xpath, value string) predicate.Predicate {
-	return predicate.ExtractedValueAccepted(extractor.UpperCaseExtractor(extractor.ExtractXPathString(xpath)),
+	return predicate.ExtractedValueAccepted(extractor.UpperCaseExtractor(extractXPathString(xpath)),
 		predicate.StringEquals(strings.ToUpper(value)))
 }
 
 // BodyXPathMatches checks to see if the result of the xpath expression, matches the regular expression given in the
 // 'pattern' parameter.
 func BodyXPathMatches(xpath string, pattern *regexp.Regexp) predicate.Predicate {
-	return predicate.ExtractedValueAccepted(extractor.ExtractXPathString(xpath), predicate.StringMatches(pattern))
+	return predicate.ExtractedValueAccepted(extractXPathString(xpath), predicate.StringMatches(pattern))
+}
+
+// BodyXPathAccepted checks to see if the string result of the xpath expression is accepted by the predicate given.
+func BodyXPathAccepted(xpath string, p predicate.Predicate) predicate.Predicate {
+	return predicate.ExtractedValueAccepted(extractXPathString(xpath), p)
+}
+
+// BodyXPathExists checks to see if the xpath expression selects anything in the XML body of the request.
+func BodyXPathExists(xpath string) predicate.Predicate {
+	path := xmlpath.MustCompile(xpath)
+	return predicate.PredicateFunc(func(r interface{}) bool {
+		root, err := xmlpath.Parse(bytes.NewReader(requestBody(r.(*http.Request))))
+		return err == nil && path.Exists(root)
+	})
+}
+
+// BodyJSONPathExists checks to see if the JSONPath expression, as described by ExtractJSONPath, selects a value in the
+// JSON body of the request.
+func BodyJSONPathExists(path string) predicate.Predicate {
+	return predicate.ExtractedValueAccepted(ExtractJSONPath(path), predicate.PredicateFunc(func(v interface{}) bool {
+		return v != nil
+	}))
+}
+
+// BodyJSONPathAccepted checks to see if the JSONPath expression, as described by ExtractJSONPath, selects a value in
+// the JSON body of the request that is accepted by the predicate given.  The predicate is passed the value as a
+// string.
+func BodyJSONPathAccepted(path string, p predicate.Predicate) predicate.Predicate {
+	return predicate.ExtractedValueAccepted(ExtractJSONPath(path), predicate.PredicateFunc(func(v interface{}) bool {
+		return v != nil && p.Accept(v)
+	}))
+}
+
+// JSONOption modifies the comparison made by BodyEqualsJSON.
+type JSONOption int
+
+const (
+	// IgnoreArrayOrder compares arrays regardless of the order of their elements.
+	IgnoreArrayOrder JSONOption = iota
+	// IgnoreExtraElements allows the body to have object members and array elements the expected JSON does not.
+	IgnoreExtraElements
+)
+
+// BodyEqualsJSON checks to see if the body of the request is JSON equal to the expected JSON, the formatting and the
+// order of object members are not significant, e.g.
+//
+//    BodyEqualsJSON(`{"id": 1, "tags": ["a", "b"]}`, IgnoreArrayOrder)
+//
+func BodyEqualsJSON(expected string, options ...JSONOption) predicate.Predicate {
+	p, err := BodyEqualsJSONE(expected, options...)
+	if err != nil {
+		panic(fmt.Sprintf("Parsing JSON for BodyEqualsJSON. error = %s", err.Error()))
+	}
+	return p
+}
+
+// BodyEqualsJSONE is like BodyEqualsJSON but returns an error if the expected JSON can't be parsed.
+func BodyEqualsJSONE(expected string, options ...JSONOption) (predicate.Predicate, error) {
+	var expectedValue interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		return nil, err
+	}
+	var ignoreArrayOrder, ignoreExtraElements bool
+	for _, option := range options {
+		switch option {
+		case IgnoreArrayOrder:
+			ignoreArrayOrder = true
+		case IgnoreExtraElements:
+			ignoreExtraElements = true
+		}
+	}
+	return predicate.PredicateFunc(func(r interface{}) bool {
+		var actual interface{}
+		if err := json.Unmarshal(requestBody(r.(*http.Request)), &actual); err != nil {
+			return false
+		}
+		return jsonEqual(expectedValue, actual, ignoreArrayOrder, ignoreExtraElements)
+	}), nil
+}
+
+func jsonEqual(expected, actual interface{}, ignoreArrayOrder, ignoreExtraElements bool) bool {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || (!ignoreExtraElements && len(a) != len(e)) {
+			return false
+		}
+		for name, value := range e {
+			actualValue, ok := a[name]
+			if !ok || !jsonEqual(value, actualValue, ignoreArrayOrder, ignoreExtraElements) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) < len(e) || (!ignoreExtraElements && len(a) != len(e)) {
+			return false
+		}
+		if !ignoreArrayOrder {
+			for i := range e {
+				if !jsonEqual(e[i], a[i], ignoreArrayOrder, ignoreExtraElements) {
+					return false
+				}
+			}
+			return true
+		}
+		used := make([]bool, len(a))
+		for _, value := range e {
+			found := false
+			for i, actualValue := range a {
+				if !used[i] && jsonEqual(value, actualValue, ignoreArrayOrder, ignoreExtraElements) {
+					used[i], found = true, true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// xmlNode is an element of an XML document reduced to what is significant when comparing documents.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+func parseXMLNode(data []byte) (*xmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		current := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name}
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "xmlns" && !(attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					node.attrs = append(node.attrs, attr)
+				}
+			}
+			sort.Slice(node.attrs, func(i, j int) bool {
+				if node.attrs[i].Name.Space != node.attrs[j].Name.Space {
+					return node.attrs[i].Name.Space < node.attrs[j].Name.Space
+				}
+				return node.attrs[i].Name.Local < node.attrs[j].Name.Local
+			})
+			current.children = append(current.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			current.text += strings.TrimSpace(string(t))
+		}
+	}
+	if len(root.children) != 1 {
+		return nil, fmt.Errorf("XML must have exactly one root element")
+	}
+	return root.children[0], nil
+}
+
+// BodyEqualsXML checks to see if the body of the request is XML equal to the expected XML.  White space between
+// elements, the order of attributes and namespace prefixes are not significant.
+func BodyEqualsXML(expected string) predicate.Predicate {
+	p, err := BodyEqualsXMLE(expected)
+	if err != nil {
+		panic(fmt.Sprintf("Parsing XML for BodyEqualsXML. error = %s", err.Error()))
+	}
+	return p
+}
+
+// BodyEqualsXMLE is like BodyEqualsXML but returns an error if the expected XML can't be parsed.
+func BodyEqualsXMLE(expected string) (predicate.Predicate, error) {
+	expectedNode, err := parseXMLNode([]byte(expected))
+	if err != nil {
+		return nil, err
+	}
+	return predicate.PredicateFunc(func(r interface{}) bool {
+		actual, err := parseXMLNode(requestBody(r.(*http.Request)))
+		return err == nil && reflect.DeepEqual(expectedNode, actual)
+	}), nil
+}
+
+// ExtractJSONPath returns an Extractor that expects a *http.Request and uses the JSONPath expression to extract a value
+// from the JSON Body of the request.  Only simple paths made of member and index steps are supported, e.g.
+// "$.orders[0].id" or "$['orders'][0]['id']".  Objects and arrays are returned as their JSON encoding and scalar
+// values as strings.  If the body is not JSON or the path does not exist nil is returned.  The body of the request is
+// restored so that it may be read again.
+func ExtractJSONPath(path string) extractor.Extractor {
+	e, err := ExtractJSONPathE(path)
+	if err != nil {
+		panic(err.Error())
+	}
+	return e
+}
+
+// ExtractJSONPathE is like ExtractJSONPath but returns an error if the JSONPath expression is not supported.
+func ExtractJSONPathE(path string) (extractor.Extractor, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return extractor.ExtractorFunc(func(r interface{}) interface{} {
+		value, ok := lookupJSONPath(requestBody(r.(*http.Request)), steps)
+		if !ok {
+			return nil
+		}
+		return value
+	}), nil
+}
+
+type jsonPathStep struct {
+	name  string
+	index int
+}
+
+// parseJSONPath breaks a JSONPath expression into steps.  An index step has an empty name.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with '$'", path)
+	}
+	steps := make([]jsonPathStep, 0, 5)
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q has an empty member name", path)
+			}
+			steps = append(steps, jsonPathStep{name: name})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unterminated '['", path)
+			}
+			key := rest[1:end]
+			if len(key) >= 2 && (key[0] == '\'' || key[0] == '"') && key[len(key)-1] == key[0] {
+				steps = append(steps, jsonPathStep{name: key[1 : len(key)-1]})
+			} else if index, err := strconv.Atoi(key); err == nil {
+				steps = append(steps, jsonPathStep{index: index})
+			} else {
+				return nil, fmt.Errorf("JSONPath %q has an unsupported subscript [%s]", path, key)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q is not supported", path)
+		}
+	}
+	return steps, nil
+}
+
+func lookupJSONPath(body []byte, steps []jsonPathStep) (interface{}, bool) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, false
+	}
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if step.name == "" {
+				return nil, false
+			}
+			var ok bool
+			if value, ok = v[step.name]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i := step.index
+			if i < 0 {
+				i += len(v)
+			}
+			if step.name != "" || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded), true
+	case nil:
+		return "null", true
+	default:
+		return fmt.Sprint(v), true
+	}
 }
@@ -3,10 +3,13 @@ package httpmock
 import (
 	"github.com/bluesoftdev/go-http-matchers/predicate"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -40,3 +43,91 @@ func TestBodyXPath(t *testing.T) {
 		})
 	}
 }
+
+var jsonPathTests = []struct {
+	Path     string
+	Expected interface{}
+}{
+	{"$.name", "joe"},
+	{"$['name']", "joe"},
+	{"$.age", "28"},
+	{"$.address.city", "Denver"},
+	{"$.tags[1]", "b"},
+	{"$.tags[-1]", "c"},
+	{"$.tags", `["a","b","c"]`},
+	{"$.spouse", "null"},
+	{"$.missing", nil},
+	{"$.tags[5]", nil},
+	{"$.name.first", nil},
+}
+
+func TestExtractJSONPath(t *testing.T) {
+	for _, tst := range jsonPathTests {
+		t.Run(tst.Path, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/foo", strings.NewReader(
+				`{"name": "joe", "age": 28, "address": {"city": "Denver"}, "tags": ["a", "b", "c"], "spouse": null}`))
+			assert.Equal(t, tst.Expected, ExtractJSONPath(tst.Path).Extract(request))
+			body, err := ioutil.ReadAll(request.Body)
+			assert.NoError(t, err)
+			assert.Contains(t, string(body), "Denver")
+		})
+	}
+}
+
+func TestExtractJSONPathNotJSON(t *testing.T) {
+	request := httptest.NewRequest("POST", "/foo", strings.NewReader("<name>joe</name>"))
+	assert.Nil(t, ExtractJSONPath("$.name").Extract(request))
+}
+
+func TestExtractJSONPathInvalid(t *testing.T) {
+	for _, path := range []string{"name", "$..name", "$.tags[*]", "$.tags[0"} {
+		assert.Panics(t, func() { ExtractJSONPath(path) }, path)
+	}
+}
+
+var bodyPredicateTests = []struct {
+	Name           string
+	Pred           predicate.Predicate
+	Body           string
+	ExpectedResult bool
+}{
+	{"BodyEquals Match", BodyEquals("hello"), "hello", true},
+	{"BodyEquals No Match", BodyEquals("hello"), "hello there", false},
+	{"BodyContains Match", BodyContains("there"), "hello there", true},
+	{"BodyMatches Match", BodyMatches(regexp.MustCompile("^h.*o$")), "hello", true},
+	{"BodyEqualsJSON Match", BodyEqualsJSON(`{"a": 1, "b": [1, 2]}`), `{"b": [1, 2.0], "a": 1}`, true},
+	{"BodyEqualsJSON Array Order", BodyEqualsJSON(`{"b": [1, 2]}`), `{"b": [2, 1]}`, false},
+	{"BodyEqualsJSON IgnoreArrayOrder", BodyEqualsJSON(`{"b": [1, 2]}`, IgnoreArrayOrder), `{"b": [2, 1]}`, true},
+	{"BodyEqualsJSON Extra", BodyEqualsJSON(`{"a": 1}`), `{"a": 1, "b": 2}`, false},
+	{"BodyEqualsJSON IgnoreExtraElements", BodyEqualsJSON(`{"a": [1]}`, IgnoreExtraElements), `{"a": [1, 3], "b": 2}`, true},
+	{"BodyEqualsJSON Missing", BodyEqualsJSON(`{"a": 1, "b": 2}`, IgnoreExtraElements), `{"a": 1}`, false},
+	{"BodyEqualsJSON Not JSON", BodyEqualsJSON(`{"a": 1}`), `a=1`, false},
+	{"BodyJSONPathExists Match", BodyJSONPathExists("$.a.b"), `{"a": {"b": null}}`, true},
+	{"BodyJSONPathExists No Match", BodyJSONPathExists("$.a.c"), `{"a": {"b": null}}`, false},
+	{"BodyJSONPathAccepted Match", BodyJSONPathAccepted("$.a", predicate.StringEquals("1")), `{"a": 1}`, true},
+	{"BodyJSONPathAccepted Missing", BodyJSONPathAccepted("$.b", predicate.True()), `{"a": 1}`, false},
+	{"BodyEqualsXML Match", BodyEqualsXML(`<a x="1" y="2"><b>c</b></a>`), "<a y=\"2\" x=\"1\">\n  <b>c</b>\n</a>", true},
+	{"BodyEqualsXML Namespaces", BodyEqualsXML(`<p:a xmlns:p="urn:x"/>`), `<q:a xmlns:q="urn:x"></q:a>`, true},
+	{"BodyEqualsXML No Match", BodyEqualsXML(`<a><b>c</b></a>`), `<a><b>d</b></a>`, false},
+	{"BodyXPathExists Match", BodyXPathExists("/a/b"), `<a><b/></a>`, true},
+	{"BodyXPathExists No Match", BodyXPathExists("/a/c"), `<a><b/></a>`, false},
+	{"BodyXPathAccepted Match", BodyXPathAccepted("/a/b", predicate.StringContains("ell")), `<a><b>hello</b></a>`, true},
+}
+
+func TestBodyPredicates(t *testing.T) {
+	for _, tst := range bodyPredicateTests {
+		t.Run(tst.Name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/foo", strings.NewReader(tst.Body))
+			assert.Equal(t, tst.ExpectedResult, tst.Pred.Accept(request))
+			body, err := ioutil.ReadAll(request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tst.Body, string(body))
+		})
+	}
+}
+
+func TestBodyPredicatesCombined(t *testing.T) {
+	request := httptest.NewRequest("POST", "/foo", strings.NewReader(`<snafu><foo>bar</foo></snafu>`))
+	assert.True(t, predicate.And(BodyXPathEquals("/snafu/foo", "bar"), BodyContains("snafu"),
+		BodyXPathExists("/snafu")).Accept(request))
+}
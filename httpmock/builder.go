@@ -0,0 +1,211 @@
+package httpmock
+
+import (
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"github.com/bluesoftdev/mockery/httpmock/internal/uuid"
+	"net/http"
+	"sync"
+)
+
+// Builder holds the state of a mockery while it is being configured.  Every DSL element is available as a method on
+// the Builder, the top level DSL functions are wrappers that apply the element to the Builder established by Mockery.
+// Distinct Builders share no state, so any number of mocks may be built concurrently using New.  A single Builder
+// must not be used from more than one goroutine at a time.
+type Builder struct {
+	mockery   *mockery
+	handlers  byPriority
+	handler   http.Handler
+	sw        *switchCaseSet
+	weighted  *weightedSet
+	steps     *sequenceSet
+	scenario  *scenario
+	condition predicate.Predicate
+	location  string
+	errors    []*ConfigError
+
+	// scope names the endpoint and method being defined, e.g. "GET /orders", for the errors reported by Expect.
+	scope string
+
+	// transitions collects the TransitionTo elements of the endpoint being defined that are made when it is selected,
+	// for the requests with the method, if it is set.  It is nil within the responses of a Switch, When, Weighted or
+	// Sequence, whose transitions are made once the response has been served.
+	transitions *[]scenarioTransition
+	method      string
+
+	// expectations are the expectations declared by Expect, they are registered with the mockery only once the
+	// configuration has succeeded.
+	expectations []*expectation
+
+	// isolated is set when the endpoints must not share the mockery's ServeMux, e.g. when they are added to a
+	// mockery that is already serving requests.
+	isolated bool
+}
+
+// NewBuilder returns a Builder for a new, empty mockery.  Most callers should use New, NewBuilder is useful when the
+// configuration is assembled incrementally.  Call Build once the configuration is complete.
+func NewBuilder() *Builder {
+	return &Builder{
+		mockery:  &mockery{journal: newJournal(DefaultJournalCapacity)},
+		handlers: make(byPriority, 0, 10),
+		handler:  NoopHandler,
+	}
+}
+
+// Build finishes the configuration and returns the mock handler.  It panics with the ConfigErrors if any were
+// recorded, use BuildE to have them returned.
+func (b *Builder) Build() Mock {
+	m, err := b.BuildE()
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// BuildE finishes the configuration and returns the mock handler, or the ConfigErrors recorded while configuring it.
+func (b *Builder) BuildE() (Mock, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	m := b.mockery
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.setHandlers(b.handlers)
+	m.initialHandlers = m.handlers
+	m.initialMux = m.mux
+	m.initialMuxEndpoints = m.muxEndpoints
+	m.addExpectations(b)
+	m.initialExpectations = m.expectations
+	return m, nil
+}
+
+// Mock returns the mock being configured.  It must not serve requests until the configuration is complete, but it
+// may be captured by DSL elements that need it at runtime.
+func (b *Builder) Mock() Mock {
+	return b.mockery
+}
+
+// handle registers the handler with the mockery's ServeMux for the url given.
+func (b *Builder) handle(url, location string, handler http.Handler) {
+	id := uuid.New()
+	b.claimExpectations(id)
+	m := b.mockery
+	if m.mux == nil {
+		m.mux = http.NewServeMux()
+		m.muxHandler = &servingMux{m: m}
+		b.handleForCondition(EndpointInfo{Name: "ServeMux", Priority: DefaultPriority, Location: location},
+			predicate.PredicateFunc(m.muxAccepts), m.muxHandler, nil)
+	}
+	m.mux.Handle(url, handler)
+	m.muxEndpoints = append(m.muxEndpoints, &muxEndpoint{
+		EndpointInfo: EndpointInfo{ID: id, Name: url, Priority: DefaultPriority, Location: location},
+		handler:      handler})
+}
+
+// handleForCondition adds a handler that is selected by the predicate given and makes the transitions when it is.
+func (b *Builder) handleForCondition(info EndpointInfo, predicate predicate.Predicate, handler http.Handler,
+	transitions []scenarioTransition) {
+	if info.ID == "" {
+		info.ID = uuid.New()
+	}
+	b.claimExpectations(info.ID)
+	b.handlers = append(b.handlers, &mockeryHandler{info, predicate, handler, transitions})
+}
+
+// DefinedAt overrides the location recorded for the endpoints defined within the configFunc.  It is useful for DSL
+// elements that define endpoints from other sources, e.g. the wiremock loader records the mapping file.
+func DefinedAt(location string, configFunc func()) {
+	CurrentBuilder().DefinedAt(location, configFunc)
+}
+
+// DefinedAt overrides the location recorded for the endpoints defined within the configFunc.
+func (b *Builder) DefinedAt(location string, configFunc func()) {
+	outerLocation := b.location
+	b.location = location
+	configFunc()
+	b.location = outerLocation
+}
+
+// definedAt returns the location overridden by DefinedAt or the location given.
+func (b *Builder) definedAt(location string) string {
+	if b.location != "" {
+		return b.location
+	}
+	return location
+}
+
+// and combines the predicate with the conditions that apply to every endpoint defined in the current context, such as
+// those added by InState.
+func (b *Builder) and(p predicate.Predicate) predicate.Predicate {
+	if b.condition == nil {
+		return p
+	}
+	return AllOf(b.condition, p)
+}
+
+// New is the builder scoped equivalent of Mockery.  The configFunc is passed the Builder which is used to define the
+// mocks, e.g.
+//
+//    mock := httpmock.New(func(b *httpmock.Builder) {
+//      b.Endpoint("/foo/bar", func() {
+//        b.Method("GET", func() {
+//          b.RespondWithString(200, "snafu")
+//        })
+//      })
+//    })
+//
+// New may be called from many goroutines at once.
+func New(configFunc func(b *Builder)) Mock {
+	b := NewBuilder()
+	b.configure(func() { configFunc(b) })
+	return b.Build()
+}
+
+// NewE is like New but returns the configuration errors instead of panicking, see MockeryE.
+func NewE(configFunc func(b *Builder)) (Mock, error) {
+	b := NewBuilder()
+	b.configure(func() { configFunc(b) })
+	return b.BuildE()
+}
+
+var (
+	// mockeryLock is held while the config function of Mockery or MockeryE runs, as it sets the current Builder.
+	mockeryLock sync.Mutex
+
+	currentBuilderLock sync.RWMutex
+	currentBuilder     *Builder
+)
+
+// CurrentBuilder returns the Builder that the top level DSL functions apply to.  It is only available while the
+// config function passed to Mockery is running.  It is useful for writing DSL elements that accept a Builder, such as
+// those in the wiremock package, so that they may be used with both Mockery and New.
+func CurrentBuilder() *Builder {
+	currentBuilderLock.RLock()
+	defer currentBuilderLock.RUnlock()
+	if currentBuilder == nil {
+		panic("httpmock: DSL function called outside of Mockery(), use New() and the Builder methods instead")
+	}
+	return currentBuilder
+}
+
+func setCurrentBuilder(b *Builder) {
+	currentBuilderLock.Lock()
+	defer currentBuilderLock.Unlock()
+	currentBuilder = b
+}
+
+// configureCurrent calls the configFunc with a new Builder as the current Builder and returns it.  Only one Builder
+// can be current, so a call while another configFunc is running, whether from within it, e.g. a StartServer within
+// Mockery, from a goroutine it started or from an unrelated goroutine, is returned as an error instead of waiting,
+// possibly for itself.
+func configureCurrent(configFunc func()) (*Builder, error) {
+	if !mockeryLock.TryLock() {
+		return nil, ConfigErrors{{Location: dslLocation(),
+			Message: "Mockery must not be used while another Mockery is being configured, use New instead"}}
+	}
+	defer mockeryLock.Unlock()
+	b := NewBuilder()
+	setCurrentBuilder(b)
+	defer setCurrentBuilder(nil)
+	b.configure(configFunc)
+	return b, nil
+}
@@ -0,0 +1,148 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/go-http-matchers/extractor"
+	. "github.com/bluesoftdev/go-http-matchers/predicate"
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Header("SNAFU", "BAZ")
+		b.Endpoint("/foo/bar", func() {
+			b.Method("GET", func() {
+				b.Header("FOO", "BAR")
+				b.RespondWithFile(500, "testdata/error.json")
+			})
+		})
+		b.EndpointPattern("/snafu/.*", func() {
+			b.Switch(ExtractQueryParameter("foo"), func() {
+				b.Case(StringEquals("bar"), func() {
+					b.RespondWithString(200, "bar")
+				})
+				b.Default(func() {
+					b.Respond(400)
+				})
+			})
+		})
+	})
+
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest("GET", "/foo/bar", nil))
+	assert.Equal(t, 500, mockWriter.Code)
+	assert.Equal(t, "{\"error\": \"This is an error\"}", mockWriter.Body.String())
+	assert.Equal(t, "BAR", mockWriter.Header().Get("FOO"))
+
+	mockWriter = httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest("GET", "/snafu/1?foo=bar", nil))
+	assert.Equal(t, 200, mockWriter.Code)
+	assert.Equal(t, "bar", mockWriter.Body.String())
+
+	mockWriter = httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest("GET", "/snafu/1?foo=baz", nil))
+	assert.Equal(t, 400, mockWriter.Code)
+}
+
+func TestBuildConcurrently(t *testing.T) {
+	const builders = 50
+	mocks := make([]Mock, builders*2)
+	var wg sync.WaitGroup
+	// Only one Mockery may be configured at a time, so they are built one after another while the New run alongside.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < builders; i++ {
+			mocks[builders+i] = Mockery(func() {
+				Endpoint(fmt.Sprintf("/mockery/%d", i), func() {
+					Method("GET", func() {
+						Header("X-Mock", fmt.Sprintf("%d", i))
+						RespondWithString(200, fmt.Sprintf("mockery %d", i))
+					})
+				})
+			})
+		}
+	}()
+	for i := 0; i < builders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mocks[i] = New(func(b *Builder) {
+				b.Endpoint(fmt.Sprintf("/new/%d", i), func() {
+					b.Method("GET", func() {
+						b.Header("X-Mock", fmt.Sprintf("%d", i))
+						b.RespondWithString(200, fmt.Sprintf("new %d", i))
+					})
+				})
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < builders; i++ {
+		mockWriter := httptest.NewRecorder()
+		mocks[i].ServeHTTP(mockWriter, httptest.NewRequest("GET", fmt.Sprintf("/new/%d", i), nil))
+		assert.Equal(t, 200, mockWriter.Code)
+		assert.Equal(t, fmt.Sprintf("new %d", i), mockWriter.Body.String())
+		assert.Equal(t, []string{fmt.Sprintf("%d", i)}, mockWriter.Header()["X-Mock"])
+
+		mockWriter = httptest.NewRecorder()
+		mocks[builders+i].ServeHTTP(mockWriter, httptest.NewRequest("GET", fmt.Sprintf("/mockery/%d", i), nil))
+		assert.Equal(t, 200, mockWriter.Code)
+		assert.Equal(t, fmt.Sprintf("mockery %d", i), mockWriter.Body.String())
+		assert.Equal(t, []string{fmt.Sprintf("%d", i)}, mockWriter.Header()["X-Mock"])
+	}
+}
+
+func TestNestedMockery(t *testing.T) {
+	const nested = "Mockery must not be used while another Mockery is being configured"
+	var inner, fromGoroutine Mock
+	var innerErr, goroutineErr error
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		_, err = MockeryE(func() {
+			inner, innerErr = MockeryE(func() {
+				Endpoint("/inner", func() {})
+			})
+			started := make(chan struct{})
+			go func() {
+				defer close(started)
+				fromGoroutine, goroutineErr = MockeryE(func() {})
+			}()
+			<-started
+			Endpoint("/outer", func() {})
+			Mockery(func() {})
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a nested MockeryE must not block")
+	}
+	assert.Nil(t, inner)
+	if assert.IsType(t, ConfigErrors{}, innerErr) {
+		assert.Contains(t, innerErr.Error(), nested)
+	}
+	assert.Nil(t, fromGoroutine)
+	if assert.IsType(t, ConfigErrors{}, goroutineErr) {
+		assert.Contains(t, goroutineErr.Error(), nested)
+	}
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 1) {
+		assert.Regexp(t, `builder_test\.go:\d+.*: `+nested, err.Error())
+	}
+
+	ft := &fakeT{}
+	_, err = MockeryE(func() {
+		StartServer(ft, ordersConfig)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ft.fatals, 1)
+}
@@ -14,16 +14,24 @@ type when struct {
 
 // When can be used within a Method's config function to conditionally choose one Response or another.
 func When(predicate predicate.Predicate, trueResponseBuilder func(), falseResponseBuilder func()) {
+	CurrentBuilder().When(predicate, trueResponseBuilder, falseResponseBuilder)
+}
+
+// When can be used within a Method's config function to conditionally choose one Response or another.
+func (b *Builder) When(predicate predicate.Predicate, trueResponseBuilder func(), falseResponseBuilder func()) {
+	outerTransitions := b.transitions
+	b.transitions = nil
+	defer func() { b.transitions = outerTransitions }()
 
-	outerMockMethodHandler := currentMockHandler
+	outerMockMethodHandler := b.handler
 	trueResponseBuilder()
-	trueMockMethod := currentMockHandler
+	trueMockMethod := b.handler
 
-	currentMockHandler = outerMockMethodHandler
+	b.handler = outerMockMethodHandler
 	falseResponseBuilder()
-	falseMockMethod := currentMockHandler
+	falseMockMethod := b.handler
 
-	currentMockHandler = &when{predicate, trueMockMethod, falseMockMethod}
+	b.handler = &when{predicate, trueMockMethod, falseMockMethod}
 }
 
 func (wh *when) ServeHTTP(w http.ResponseWriter, request *http.Request) {
@@ -56,44 +64,86 @@ func (scs *switchCaseSet) ServeHTTP(w http.ResponseWriter, request *http.Request
 	scs.defaultHandler.ServeHTTP(w, request)
 }
 
-var currentSwitch *switchCaseSet
-
 // Switch can be used within a Method's config function to conditionally choose one of many possible responses.  The
 // first Case whose predicate returns true will be selected.  Otherwise the Response defined in the Default is used.
 // If there is no Default, then 404 is returned with an empty Body.
 func Switch(keySupplier extractor.Extractor, cases func()) {
-	handler := currentMockHandler
+	CurrentBuilder().Switch(keySupplier, cases)
+}
+
+// Switch can be used within a Method's config function to conditionally choose one of many possible responses.
+func (b *Builder) Switch(keySupplier extractor.Extractor, cases func()) {
+	b.switchOn(keySupplier, false, cases)
+}
+
+// switchOn defines a Switch.  If unmatched is true a request no case matches, e.g. one with a method its Endpoint has
+// no Method for, is counted as unmatched.  A Switch used on purpose may well answer 404 when no case matches, so only
+// the Switch of an Endpoint counts them.
+func (b *Builder) switchOn(keySupplier extractor.Extractor, unmatched bool, cases func()) {
+	handler := b.handler
+	m := b.mockery
 	sw := &switchCaseSet{
 		keySupplier: keySupplier,
 		switchCases: make([]*switchCase, 0, 10),
-		defaultHandler: http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
-			handler.ServeHTTP(w, request)
-			w.WriteHeader(404)
-		}),
 	}
-	outerSwitch := currentSwitch
-	currentSwitch = sw
+	sw.defaultHandler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		handler.ServeHTTP(w, request)
+		if rw, ok := w.(*recordingResponseWriter); ok && unmatched {
+			rw.unmatched = true
+		}
+		if m.diagnose {
+			writeDiagnostic(w, diagnoseSwitch(sw, keySupplier.Extract(request)))
+		} else {
+			w.WriteHeader(404)
+		}
+	})
+	outerSwitch := b.sw
+	b.sw = sw
 	cases()
-	currentMockHandler = currentSwitch
-	currentSwitch = outerSwitch
+	b.handler = b.sw
+	b.sw = outerSwitch
 }
 
 // Case used within a Switch to define a Response that will be returned if the case's predicate is true.  The order of
 // the case calls matter as the first to match will be used.
 func Case(predicate predicate.Predicate, responseBuilder func()) {
-	outerMockMethodHandler := currentMockHandler
+	CurrentBuilder().Case(predicate, responseBuilder)
+}
+
+// Case used within a Switch to define a Response that will be returned if the case's predicate is true.
+func (b *Builder) Case(predicate predicate.Predicate, responseBuilder func()) {
+	b.addCase("Case", "a Switch", predicate, responseBuilder)
+}
+
+// addCase adds a case to the current Switch, the element and container name the DSL element for the error recorded
+// when there is no Switch.
+func (b *Builder) addCase(element, container string, predicate predicate.Predicate, responseBuilder func()) {
+	if b.sw == nil {
+		b.Errorf("%s must be used within %s", element, container)
+		return
+	}
+	outerMockMethodHandler := b.handler
+	outerTransitions := b.transitions
+	b.transitions = nil
 	responseBuilder()
-	responseMockMethod := currentMockHandler
+	b.transitions = outerTransitions
+	responseMockMethod := b.handler
 	if predicate != nil {
-		currentSwitch.switchCases = append(currentSwitch.switchCases, &switchCase{predicate, responseMockMethod})
+		predicate = described(predicate, "the condition given to "+element+" at "+b.definedAt(dslLocation()))
+		b.sw.switchCases = append(b.sw.switchCases, &switchCase{predicate, responseMockMethod})
 	} else {
-		currentSwitch.defaultHandler = responseMockMethod
+		b.sw.defaultHandler = responseMockMethod
 	}
-	currentMockHandler = outerMockMethodHandler
+	b.handler = outerMockMethodHandler
 }
 
 // Default used to define the Response that will be returned when no other case is triggered.  The default can be placed
 // anywhere but there can only be one.
 func Default(responseBuilder func()) {
-	Case(nil, responseBuilder)
+	CurrentBuilder().Default(responseBuilder)
+}
+
+// Default used to define the Response that will be returned when no other case is triggered.
+func (b *Builder) Default(responseBuilder func()) {
+	b.addCase("Default", "a Switch", nil, responseBuilder)
 }
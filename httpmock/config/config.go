@@ -0,0 +1,319 @@
+package config
+
+import (
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"github.com/bluesoftdev/mockery/httpmock"
+	"gopkg.in/yaml.v2"
+
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+type configDelay struct {
+	Fixed   string `yaml:"fixed"`
+	Uniform *struct {
+		Min string `yaml:"min"`
+		Max string `yaml:"max"`
+	} `yaml:"uniform"`
+	Normal *struct {
+		Mean   string `yaml:"mean"`
+		StdDev string `yaml:"stdDev"`
+		Max    string `yaml:"max"`
+	} `yaml:"normal"`
+	SmoothedNormal *struct {
+		Mean      string  `yaml:"mean"`
+		StdDev    string  `yaml:"stdDev"`
+		Max       string  `yaml:"max"`
+		Smoothing float64 `yaml:"smoothing"`
+	} `yaml:"smoothedNormal"`
+	LogNormal *struct {
+		Median string  `yaml:"median"`
+		Sigma  float64 `yaml:"sigma"`
+		Max    string  `yaml:"max"`
+	} `yaml:"logNormal"`
+	Exponential *struct {
+		Mean string `yaml:"mean"`
+		Max  string `yaml:"max"`
+	} `yaml:"exponential"`
+	Pareto *struct {
+		Min   string  `yaml:"min"`
+		Alpha float64 `yaml:"alpha"`
+		Max   string  `yaml:"max"`
+	} `yaml:"pareto"`
+	Empirical map[float64]string `yaml:"empirical"`
+	Queueing  *struct {
+		ServiceTime string `yaml:"serviceTime"`
+		Capacity    int    `yaml:"capacity"`
+	} `yaml:"queueing"`
+}
+
+type configChunkedDribble struct {
+	Chunks   int    `yaml:"chunks"`
+	Duration string `yaml:"duration"`
+}
+
+type configRateLimit struct {
+	Rps   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+type configResponse struct {
+	Status   int               `yaml:"status"`
+	Headers  map[string]string `yaml:"headers"`
+	Body     string            `yaml:"body"`
+	Json     interface{}       `yaml:"json"`
+	File     string            `yaml:"file"`
+	Template string            `yaml:"template"`
+	ProxyTo  string            `yaml:"proxyTo"`
+	Fault    string            `yaml:"fault"`
+	Delay    *configDelay      `yaml:"delay"`
+
+	BytesPerSecond int                   `yaml:"bytesPerSecond"`
+	ChunkedDribble *configChunkedDribble `yaml:"chunkedDribble"`
+	RateLimit      *configRateLimit      `yaml:"rateLimit"`
+	MaxConcurrent  int                   `yaml:"maxConcurrent"`
+}
+
+type configEndpoint struct {
+	Name          string            `yaml:"name"`
+	Path          string            `yaml:"path"`
+	PathPattern   string            `yaml:"pathPattern"`
+	Method        string            `yaml:"method"`
+	Priority      *int              `yaml:"priority"`
+	Headers       map[string]string `yaml:"headers"`
+	Query         map[string]string `yaml:"query"`
+	Scenario      string            `yaml:"scenario"`
+	RequiredState string            `yaml:"requiredState"`
+	NewState      string            `yaml:"newState"`
+	Response      configResponse    `yaml:"response"`
+}
+
+type config struct {
+	JournalCapacity *int             `yaml:"journalCapacity"`
+	Endpoints       []configEndpoint `yaml:"endpoints"`
+}
+
+// ConfigEndpoints defines the endpoints described by the YAML or JSON config file given.  Files named by the config
+// are looked for relative to the directory containing the config file.  The format is:
+//
+//    journalCapacity: 500              # optional, see httpmock.JournalCapacity
+//    endpoints:
+//      - name: get-order               # optional, names the endpoint in the journal and metrics, see httpmock.Name
+//        path: /orders/1               # or pathPattern: a regular expression matched against the path
+//        method: GET                   # optional, any method by default
+//        priority: 10                  # optional, httpmock.DefaultPriority by default
+//        headers: {X-Tenant: acme}     # optional, the request headers must equal the values given
+//        query: {expand: "true"}       # optional, the query parameters must equal the values given
+//        scenario: order               # optional, see httpmock.Scenario
+//        requiredState: Started        # optional, see httpmock.InState
+//        newState: SHIPPED             # optional, see httpmock.TransitionTo
+//        response:
+//          status: 200
+//          headers: {Content-Type: application/json}
+//          body: '{"id": 1}'          # or json: a value that is encoded as the JSON body
+//                                      # or file: the name of a file holding the body
+//                                      # or template: a body template, see httpmock.RespondWithTemplate
+//                                      # or proxyTo: the base URL of an upstream, see httpmock.ProxyTo
+//                                      # or fault: e.g. CONNECTION_RESET_BY_PEER, see httpmock.Fault
+//          delay:                      # optional, one of
+//            fixed: 10ms
+//            uniform: {min: 10ms, max: 50ms}
+//            normal: {mean: 30ms, stdDev: 10ms, max: 200ms}
+//            smoothedNormal: {mean: 30ms, stdDev: 10ms, max: 200ms, smoothing: 0.1}
+//            logNormal: {median: 30ms, sigma: 0.4, max: 2s}
+//            exponential: {mean: 30ms, max: 1s}
+//            pareto: {min: 10ms, alpha: 2.5, max: 5s}
+//            empirical: {50: 20ms, 99: 300ms, 100: 1s}   # percentiles, see httpmock.EmpiricalDelay
+//            queueing: {serviceTime: 20ms, capacity: 10}   # see httpmock.QueueingDelay
+//          bytesPerSecond: 1024        # optional, see httpmock.ThrottleBandwidth
+//          chunkedDribble: {chunks: 5, duration: 1s}   # optional, see httpmock.ChunkedDribbleDelay
+//          rateLimit: {rps: 10, burst: 5}              # optional, see httpmock.RateLimit, limited requests get a 429
+//          maxConcurrent: 4                            # optional, see httpmock.MaxConcurrent, limited requests get a 503
+//
+func ConfigEndpoints(fileName string) {
+	AddConfigEndpoints(httpmock.CurrentBuilder(), fileName)
+}
+
+// AddConfigEndpoints is like ConfigEndpoints but adds the endpoints to the given builder.
+func AddConfigEndpoints(b *httpmock.Builder, fileName string) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		b.Errorf("opening config file: %s", err.Error())
+		return
+	}
+	defer f.Close()
+	c, err := parseConfig(f)
+	if err != nil {
+		b.Errorf("parsing config file %s: %s", fileName, err.Error())
+		return
+	}
+	if c.JournalCapacity != nil {
+		b.JournalCapacity(*c.JournalCapacity)
+	}
+	dir := filepath.Dir(fileName)
+	for i := range c.Endpoints {
+		e := &c.Endpoints[i]
+		b.DefinedAt(fmt.Sprintf("%s:endpoints[%d]", fileName, i), func() {
+			addEndpoint(b, dir, e)
+		})
+	}
+}
+
+// parseConfig parses a YAML config, as JSON is a subset of YAML it also parses JSON configs.
+func parseConfig(r io.Reader) (*config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var c config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func addEndpoint(b *httpmock.Builder, dir string, e *configEndpoint) {
+	predicates := make([]predicate.Predicate, 0, 5)
+	if e.Path != "" {
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("path is %q", e.Path), predicate.PathEquals(e.Path)))
+	} else if e.PathPattern != "" {
+		pattern, err := regexp.Compile(e.PathPattern)
+		if err != nil {
+			b.Errorf("invalid pathPattern %q: %s", e.PathPattern, err.Error())
+			return
+		}
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("path matches %q", e.PathPattern),
+			predicate.PathMatches(pattern)))
+	}
+	if e.Method != "" {
+		predicates = append(predicates, httpmock.Describe("method is "+e.Method, predicate.MethodIs(e.Method)))
+	}
+	for _, header := range sortedKeys(e.Headers) {
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("header %s is %q", header, e.Headers[header]),
+			predicate.HeaderEquals(header, e.Headers[header])))
+	}
+	for _, query := range sortedKeys(e.Query) {
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("query parameter %s is %q", query, e.Query[query]),
+			predicate.QueryParamEquals(query, e.Query[query])))
+	}
+	priority := httpmock.DefaultPriority
+	if e.Priority != nil {
+		priority = *e.Priority
+	}
+	endpoint := func() {
+		b.EndpointForConditionWithPriority(priority, httpmock.AllOf(predicates...), func() {
+			if e.Name != "" {
+				b.Name(e.Name)
+			}
+			responseConfig(b, dir, &e.Response)
+			if e.NewState != "" {
+				b.TransitionTo(e.NewState)
+			}
+		})
+	}
+	if e.Scenario == "" {
+		endpoint()
+		return
+	}
+	b.Scenario(e.Scenario, func() {
+		if e.RequiredState != "" {
+			b.InState(e.RequiredState, endpoint)
+		} else {
+			endpoint()
+		}
+	})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func responseConfig(b *httpmock.Builder, dir string, r *configResponse) {
+	status := r.Status
+	if status == 0 {
+		status = 200
+	}
+	for name, value := range r.Headers {
+		b.Header(name, value)
+	}
+	switch {
+	case r.Fault != "":
+		b.Fault(httpmock.FaultType(r.Fault))
+	case r.ProxyTo != "":
+		b.ProxyTo(r.ProxyTo)
+	case r.File != "":
+		b.RespondWithFile(status, filepath.Join(dir, r.File))
+	case r.Template != "":
+		b.RespondWithTemplate(status, r.Template)
+	case r.Json != nil:
+		b.RespondWithJson(status, jsonValue(r.Json))
+	case r.Body != "":
+		b.RespondWithString(status, r.Body)
+	default:
+		b.Respond(status)
+	}
+	if r.Delay != nil {
+		switch {
+		case r.Delay.Fixed != "":
+			b.FixedDelay(r.Delay.Fixed)
+		case r.Delay.Uniform != nil:
+			b.UniformDelay(r.Delay.Uniform.Min, r.Delay.Uniform.Max)
+		case r.Delay.Normal != nil:
+			b.NormalDelay(r.Delay.Normal.Mean, r.Delay.Normal.StdDev, r.Delay.Normal.Max)
+		case r.Delay.SmoothedNormal != nil:
+			b.SmoothedNormalDelay(r.Delay.SmoothedNormal.Mean, r.Delay.SmoothedNormal.StdDev, r.Delay.SmoothedNormal.Max,
+				r.Delay.SmoothedNormal.Smoothing)
+		case r.Delay.LogNormal != nil:
+			b.LogNormalDelay(r.Delay.LogNormal.Median, r.Delay.LogNormal.Sigma, r.Delay.LogNormal.Max)
+		case r.Delay.Exponential != nil:
+			b.ExponentialDelay(r.Delay.Exponential.Mean, r.Delay.Exponential.Max)
+		case r.Delay.Pareto != nil:
+			b.ParetoDelay(r.Delay.Pareto.Min, r.Delay.Pareto.Alpha, r.Delay.Pareto.Max)
+		case r.Delay.Empirical != nil:
+			b.EmpiricalDelay(r.Delay.Empirical)
+		case r.Delay.Queueing != nil:
+			b.QueueingDelay(r.Delay.Queueing.ServiceTime, r.Delay.Queueing.Capacity)
+		}
+	}
+	if r.BytesPerSecond != 0 {
+		b.ThrottleBandwidth(r.BytesPerSecond)
+	}
+	if r.ChunkedDribble != nil {
+		b.ChunkedDribbleDelay(r.ChunkedDribble.Chunks, r.ChunkedDribble.Duration)
+	}
+	if r.RateLimit != nil {
+		b.RateLimit(r.RateLimit.Rps, r.RateLimit.Burst, nil)
+	}
+	if r.MaxConcurrent != 0 {
+		b.MaxConcurrent(r.MaxConcurrent, nil)
+	}
+}
+
+// jsonValue converts the maps produced by the YAML parser, whose keys are interface{}, into maps that can be encoded
+// as JSON.
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprintf("%v", k)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	}
+	return v
+}
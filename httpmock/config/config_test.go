@@ -0,0 +1,98 @@
+package config_test
+
+import (
+	"encoding/json"
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/config"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(mock Mock, method, url string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, url, nil)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, request)
+	return w
+}
+
+func TestConfigEndpoints(t *testing.T) {
+	mock := Mockery(func() {
+		ConfigEndpoints("testdata/orders.yaml")
+	})
+
+	w := serve(mock, "GET", "http://localhost/orders/1", nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var order map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, float64(1), order["id"])
+	assert.Equal(t, "abc", order["items"].([]interface{})[0].(map[string]interface{})["sku"])
+
+	w = serve(mock, "GET", "http://localhost/orders/12/invoice", nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "INVOICE\n", w.Body.String())
+
+	w = serve(mock, "POST", "http://localhost/orders", map[string]string{"X-Tenant": "acme"})
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, "created acme", w.Body.String())
+	w = serve(mock, "POST", "http://localhost/orders", nil)
+	assert.Equal(t, 403, w.Code)
+
+	w = serve(mock, "GET", "http://localhost/orders/2", nil)
+	assert.Equal(t, "PENDING", w.Body.String())
+	w = serve(mock, "POST", "http://localhost/orders/2/ship", nil)
+	assert.Equal(t, 204, w.Code)
+	w = serve(mock, "GET", "http://localhost/orders/2", nil)
+	assert.Equal(t, "SHIPPED", w.Body.String())
+
+	w = serve(mock, "GET", "http://localhost/orders/3", nil)
+	assert.Equal(t, "LIMITED", w.Body.String())
+	w = serve(mock, "GET", "http://localhost/orders/3", nil)
+	assert.Equal(t, 429, w.Code)
+
+	assert.Len(t, mock.Requests(), 9)
+	assert.Equal(t, "get-order", mock.Requests()[0].Endpoint)
+	for _, e := range mock.Endpoints() {
+		assert.True(t, strings.HasPrefix(e.Location, "testdata/orders.yaml:endpoints["), e.Location)
+	}
+}
+
+func TestConfigEndpointsJSON(t *testing.T) {
+	mock := Mockery(func() {
+		ConfigEndpoints("testdata/orders.json")
+	})
+
+	w := serve(mock, "GET", "http://localhost/customers/1", nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "customer 1", w.Body.String())
+}
+
+func TestConfigEndpointsUnknownField(t *testing.T) {
+	assert.Panics(t, func() {
+		Mockery(func() {
+			ConfigEndpoints("testdata/unknown.yaml")
+		})
+	})
+}
+
+func TestConfigEndpointsErrors(t *testing.T) {
+	_, err := MockeryE(func() {
+		ConfigEndpoints("testdata/invalid.yaml")
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 4) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "testdata/invalid.yaml:endpoints[0]", errs[0].Location)
+		assert.Contains(t, errs[0].Message, `invalid pathPattern "/orders/[0-9"`)
+		assert.Equal(t, "testdata/invalid.yaml:endpoints[1]", errs[1].Location)
+		assert.Equal(t, "min 50ms is greater than max 10ms for UniformDelay", errs[1].Message)
+		assert.Equal(t, "testdata/invalid.yaml:endpoints[2]", errs[2].Location)
+		assert.Equal(t, "capacity for QueueingDelay must be greater than 0 but is 0", errs[2].Message)
+		assert.Equal(t, "testdata/invalid.yaml:endpoints[3]", errs[3].Location)
+		assert.Equal(t, "delay 10ms at p99 is less than the delay 20ms at p50 for EmpiricalDelay", errs[3].Message)
+	}
+}
@@ -0,0 +1,6 @@
+// Package config defines mockery/httpmock endpoints from a YAML or JSON config file,
+// see ConfigEndpoints for the format.  It is intended for mocks that are run with the
+// cmd/mockery server rather than built in Go, and covers the common cases: matching on
+// path, method, headers and query parameters, static, file, JSON, templated and
+// proxied responses, delays and scenarios.
+package config
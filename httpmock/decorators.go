@@ -12,14 +12,24 @@ import (
 
 // Header adds a header to the response, may be called at any time.
 func Header(name, value string) {
-	DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+	CurrentBuilder().Header(name, value)
+}
+
+// Header adds a header to the response, may be called at any time.
+func (b *Builder) Header(name, value string) {
+	b.DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
 		w.Header().Add(name, value)
 	}), NoopHandler)
 }
 
 // Trailer adds a trailer to the response, must be called after the response body has been specified.
 func Trailer(name, value string) {
-	DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+	CurrentBuilder().Trailer(name, value)
+}
+
+// Trailer adds a trailer to the response, must be called after the response body has been specified.
+func (b *Builder) Trailer(name, value string) {
+	b.DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
 		w.Header().Add("Trailer", name)
 	}), http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
 		w.Header().Add(name, value)
@@ -29,12 +39,23 @@ func Trailer(name, value string) {
 // RespondWithJson adds a response code and body to the response.  The jsonBody parameter is JSON encoded using the json
 // encoder in the encoding/json package.
 func RespondWithJson(status int, jsonBody interface{}) {
-	WriteStatusAndBody(status, jsonBody)
+	CurrentBuilder().RespondWithJson(status, jsonBody)
+}
+
+// RespondWithJson adds a response code and body to the response.  The jsonBody parameter is JSON encoded using the json
+// encoder in the encoding/json package.
+func (b *Builder) RespondWithJson(status int, jsonBody interface{}) {
+	b.WriteStatusAndBody(status, jsonBody)
 }
 
 // RespondWithFile responds with the status code given and the content of the file specified.
 func RespondWithFile(status int, fileName string) {
-	WriteStatusAndBody(status, func() io.ReadCloser {
+	CurrentBuilder().RespondWithFile(status, fileName)
+}
+
+// RespondWithFile responds with the status code given and the content of the file specified.
+func (b *Builder) RespondWithFile(status int, fileName string) {
+	b.WriteStatusAndBody(status, func() io.ReadCloser {
 		file, err := os.Open(fileName)
 		if err != nil {
 			log.Printf("ERROR while serving up a file: %+v", err)
@@ -46,32 +67,59 @@ func RespondWithFile(status int, fileName string) {
 
 // RespondWithString responds with the status code given and the body
 func RespondWithString(status int, body string) {
-	WriteStatusAndBody(status, body)
+	CurrentBuilder().RespondWithString(status, body)
+}
+
+// RespondWithString responds with the status code given and the body
+func (b *Builder) RespondWithString(status int, body string) {
+	b.WriteStatusAndBody(status, body)
 }
 
 // RespondWithReader responds with the status code given and the body read from the io.Reader
 func RespondWithReader(status int, bodyProducer func() io.Reader) {
-	WriteStatusAndBody(status, bodyProducer)
+	CurrentBuilder().RespondWithReader(status, bodyProducer)
+}
+
+// RespondWithReader responds with the status code given and the body read from the io.Reader
+func (b *Builder) RespondWithReader(status int, bodyProducer func() io.Reader) {
+	b.WriteStatusAndBody(status, bodyProducer)
 }
 
 // Respond responds with an empty body and the given status code.
 func Respond(status int) {
-	WriteStatusAndBody(status,"")
+	CurrentBuilder().Respond(status)
 }
 
-// LogLocation will log the given comment along with the file and line number.
-func LogLocation(comment string) {
+// Respond responds with an empty body and the given status code.
+func (b *Builder) Respond(status int) {
+	b.WriteStatusAndBody(status, "")
+}
+
+// callerLocation returns the file, line number and function name of the caller 'skip' frames above the function
+// that calls callerLocation.
+func callerLocation(skip int) string {
 	frames := make([]uintptr, 1)
-	runtime.Callers(2, frames)
+	runtime.Callers(skip+2, frames)
 	fun := runtime.FuncForPC(frames[0] - 1)
-	var fileLocation string
 	if fun == nil {
-		fileLocation = "Unknown"
-	} else {
-		file, line := fun.FileLine(frames[0])
-		fileLocation = fmt.Sprintf("%s:%d(%s)", file, line, fun.Name())
+		return "Unknown"
 	}
-	DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+	file, line := fun.FileLine(frames[0] - 1)
+	return fmt.Sprintf("%s:%d(%s)", file, line, fun.Name())
+}
+
+// LogLocation will log the given comment along with the file and line number.
+func LogLocation(comment string) {
+	CurrentBuilder().logLocation(callerLocation(1), comment)
+}
+
+// LogLocation will log the given comment along with the file and line number.
+func (b *Builder) LogLocation(comment string) {
+	b.logLocation(callerLocation(1), comment)
+}
+
+func (b *Builder) logLocation(fileLocation, comment string) {
+	b.DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
 		log.Printf("Endpoint Defined at %s: %s", fileLocation, comment)
 	}), NoopHandler)
 }
@@ -20,9 +20,18 @@ func (ch *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	*ch++
 }
 
+// useTestBuilder establishes a builder, with the given handler as its current handler, for the top level DSL
+// functions to use outside of Mockery, until the test finishes.
+func useTestBuilder(t *testing.T, handler http.Handler) {
+	b := NewBuilder()
+	b.handler = handler
+	setCurrentBuilder(b)
+	t.Cleanup(func() { setCurrentBuilder(nil) })
+}
+
 func TestRespondWithReader(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	RespondWithReader(200, func() io.Reader {
 		file, err := os.Open("./testdata/response.xml")
 		if err != nil {
@@ -38,7 +47,7 @@ func TestRespondWithReader(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, 200, result.StatusCode)
 	assert.Equal(t, countingHandler(1), counter)
@@ -51,7 +60,7 @@ type testStruct struct {
 
 func TestRespondWithJson(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	RespondWithJson(200, &testStruct{"joe", 28})
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
 	request := &http.Request{
@@ -60,7 +69,7 @@ func TestRespondWithJson(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, 200, result.StatusCode)
 	assert.Equal(t, countingHandler(1), counter)
@@ -73,7 +82,7 @@ func TestRespondWithJson(t *testing.T) {
 
 func TestRespondWithString(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	RespondWithString(200, "The quick brown fox jumped over the lazy dogs.")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
 	request := &http.Request{
@@ -82,7 +91,7 @@ func TestRespondWithString(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, 200, result.StatusCode)
 	assert.Equal(t, countingHandler(1), counter)
@@ -94,7 +103,7 @@ func TestRespondWithString(t *testing.T) {
 
 func TestRespondWithFile(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	RespondWithFile(200, "./testdata/ok.json")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
 	request := &http.Request{
@@ -103,7 +112,7 @@ func TestRespondWithFile(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, 200, result.StatusCode)
 	assert.Equal(t, countingHandler(1), counter)
@@ -115,7 +124,7 @@ func TestRespondWithFile(t *testing.T) {
 
 func TestRespondWithFileNotFound(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	RespondWithFile(200, "testdata/notok.json")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
 	request := &http.Request{
@@ -124,7 +133,7 @@ func TestRespondWithFileNotFound(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, 500, result.StatusCode)
 	assert.Equal(t, countingHandler(1), counter)
@@ -135,7 +144,7 @@ func TestRespondWithFileNotFound(t *testing.T) {
 }
 
 func TestHeader(t *testing.T) {
-	currentMockHandler = NoopHandler
+	useTestBuilder(t, NoopHandler)
 	Header("X-Test", "Bar")
 	RespondWithString(200, "The quick brown fox jumped over the lazy dogs.")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
@@ -145,13 +154,13 @@ func TestHeader(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, "Bar", result.Header.Get("X-Test"))
 }
 
 func TestTrailer(t *testing.T) {
-	currentMockHandler = NoopHandler
+	useTestBuilder(t, NoopHandler)
 	RespondWithString(200, "The quick brown fox jumped over the lazy dogs.")
 	Trailer("X-Test", "Bar")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
@@ -161,7 +170,7 @@ func TestTrailer(t *testing.T) {
 		URL:    testURL,
 	}
 	mockWriter := httptest.NewRecorder()
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	result := mockWriter.Result()
 	assert.Equal(t, "X-Test", result.Header.Get("Trailer"))
 	assert.Equal(t, "Bar", result.Trailer.Get("X-Test"))
@@ -169,7 +178,7 @@ func TestTrailer(t *testing.T) {
 
 func TestLogLocation(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	LogLocation("This is a log message")
 	RespondWithString(200, "The quick brown fox jumped over the lazy dogs.")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
@@ -181,7 +190,7 @@ func TestLogLocation(t *testing.T) {
 	mockWriter := httptest.NewRecorder()
 	var str bytes.Buffer
 	log.SetOutput(&str)
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	logMessage := str.String()
 	assert.Regexp(t, regexp.MustCompile("[0-9/: ]{20}Endpoint Defined at [/a-zA-Z0-9_\\-]+/decorators_test.go\\:[0-9]+"+
 		"\\(github\\.com/bluesoftdev/mockery/httpmock.TestLogLocation\\)\\: This is a log message\n"), logMessage)
@@ -1,10 +1,9 @@
 package httpmock
 
 import (
-	"fmt"
-	"math"
 	"math/rand"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,130 +26,121 @@ type uniformDelay struct {
 	max time.Duration
 }
 
-type normalDelay struct {
-	delayBase
-	mean, stdDev, max float64
+// FixedDelay defines a fixed delay for the response.  The duration string should be formatted as expected by
+// time.ParseDuration
+func FixedDelay(d string) {
+	CurrentBuilder().FixedDelay(d)
 }
 
 // FixedDelay defines a fixed delay for the response.  The duration string should be formatted as expected by
 // time.ParseDuration
-func FixedDelay(d string) {
-	dd, err := time.ParseDuration(d)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing time for FixedDelay. error = %s", err.Error()))
+func (b *Builder) FixedDelay(d string) {
+	dd, ok := b.parseDuration("FixedDelay", "delay", d)
+	if !ok {
+		return
 	}
 	fd := fixedDelay{delayBase: delayBase{}, delay: dd}
 	fd.waiter = &fd
-	DecorateHandler(&fd, NoopHandler)
+	b.DecorateHandler(&fd, NoopHandler)
 }
 
 // UniformDelay defines a delay that is uniformly distributed between a minimum and a maximum.  The min and max
 // parameters are expected to conform the the format expected by time.ParseDuration
 func UniformDelay(min, max string) {
+	CurrentBuilder().UniformDelay(min, max)
+}
+
+// UniformDelay defines a delay that is uniformly distributed between a minimum and a maximum.  The min and max
+// parameters are expected to conform the the format expected by time.ParseDuration
+func (b *Builder) UniformDelay(min, max string) {
 	var ud uniformDelay
-	var err error
-	ud.min, err = time.ParseDuration(min)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing min for UniformDelay in error = %s", err.Error()))
+	var minOk, maxOk bool
+	ud.min, minOk = b.parseDuration("UniformDelay", "min", min)
+	ud.max, maxOk = b.parseDuration("UniformDelay", "max", max)
+	if !minOk || !maxOk {
+		return
 	}
-	ud.max, err = time.ParseDuration(max)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing max for UniformDelay in error = %s", err.Error()))
+	if ud.min > ud.max {
+		b.Errorf("min %s is greater than max %s for UniformDelay", min, max)
+		return
 	}
 	ud.waiter = &ud
-	DecorateHandler(&ud, NoopHandler)
+	b.DecorateHandler(&ud, NoopHandler)
 }
 
-func nextWaitTimeNormal(m, u, s float64) func() time.Duration {
-	return func() time.Duration {
-		return time.Duration(math.Min(m, math.Exp(float64(u)+float64(s)*rand.NormFloat64())) * float64(time.Second))
-	}
+// LoadDependentDelay defines a delay that depends on the number of requests in flight through it, including the
+// request being delayed, so that the latency of a downstream can grow as it saturates.  A request is in flight from
+// the moment it reaches the delay until the handler it decorates has finished with it, e.g.
+//
+//    Method("GET", func() {
+//      RespondWithString(200, "ok")
+//      LoadDependentDelay(func(inFlight int) time.Duration {
+//        return time.Duration(inFlight*inFlight) * time.Millisecond
+//      })
+//    })
+//
+func LoadDependentDelay(latency func(inFlight int) time.Duration) {
+	CurrentBuilder().LoadDependentDelay(latency)
 }
 
-// NormalDelay defines a delay whose distribution conforms to a Normal Distribution with the given mean, standard
-// deviation, and maximum.  All the durations are expressed in a format compatible with time.ParseDuration
-func NormalDelay(mean, stdDev, max string) {
-	var err error
-	meanDuration, err := time.ParseDuration(mean)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing mean for NormalDelay in error = %s", err.Error()))
+// LoadDependentDelay defines a delay that depends on the number of requests in flight through it.
+func (b *Builder) LoadDependentDelay(latency func(inFlight int) time.Duration) {
+	if latency == nil {
+		b.Errorf("latency for LoadDependentDelay must not be nil")
+		return
 	}
-	meanF := float64(meanDuration) / float64(time.Second)
-	stdDevDuration, err := time.ParseDuration(stdDev)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing stdDev for NormalDelay in error = %s", err.Error()))
-	}
-	stdDevF := float64(stdDevDuration) / float64(time.Second)
-	maxDuration, err := time.ParseDuration(max)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing max for NormalDelay in error = %s", err.Error()))
-	}
-	maxF := float64(maxDuration) / float64(time.Second)
-
-	// Calculate mu & sigma
-	a := 1 + (stdDevF*stdDevF)/math.Pow(meanF, 2)
-	// u := math.Log(meanF) - a/2
-	u := math.Log(meanF / math.Sqrt(a))
-	s := math.Sqrt(math.Log(a))
-	DecorateHandler(Waiter(nextWaitTimeNormal(maxF, u, s)), NoopHandler)
+	delegate := b.handler
+	var inFlight int64
+	b.handler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		d := latency(int(n))
+		time.Sleep(d)
+		addDelay(w, d)
+		delegate.ServeHTTP(w, request)
+	})
 }
 
-func nextWaitTimeSmoothedNormal(max, u, s float64) func() time.Duration {
-	next := make(chan time.Duration)
+// QueueingDelay defines a LoadDependentDelay that models a downstream able to serve capacity requests at once, each
+// taking serviceTime.  While no more than capacity requests are in flight each is delayed by the serviceTime, beyond
+// that the requests queue and the delay grows by a further serviceTime for every capacity requests ahead.  The
+// serviceTime is in the format expected by time.ParseDuration.
+func QueueingDelay(serviceTime string, capacity int) {
+	CurrentBuilder().QueueingDelay(serviceTime, capacity)
+}
 
-	getNext := func() float64 {
-		return math.Exp(float64(u) + float64(s)*rand.NormFloat64())
+// QueueingDelay defines a LoadDependentDelay that models a downstream able to serve capacity requests at once, each
+// taking serviceTime.
+func (b *Builder) QueueingDelay(serviceTime string, capacity int) {
+	st, ok := b.parseDuration("QueueingDelay", "serviceTime", serviceTime)
+	if !ok {
+		return
 	}
-	a1 := 0.3
-	//a2 := 0.5
-	go func() {
-		last1 := math.Exp(u)
-		//var last2 float64 = math.Exp(u)
-		for {
-			x1 := getNext()
-			n := a1*x1 + (1.0-a1)*last1
-			last1 = n
-			//n := a2*x2 + (1.0-a2)*last2
-			//last2 = n
-			next <- time.Duration(math.Min(max, n) * float64(time.Second))
-		}
-	}()
-	return func() time.Duration {
-		return <-next
+	if capacity <= 0 {
+		b.Errorf("capacity for QueueingDelay must be greater than 0 but is %d", capacity)
+		return
 	}
+	b.LoadDependentDelay(func(inFlight int) time.Duration {
+		return st * time.Duration((inFlight+capacity-1)/capacity)
+	})
 }
 
-// SmoothedNormalDelay WIP, This is an incomplete API and should not be used.
-func SmoothedNormalDelay(mean, stdDev, max string) {
-	var err error
-	meanDuration, err := time.ParseDuration(mean)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing mean for NormalDelay in error = %s", err.Error()))
-	}
-	meanF := float64(meanDuration) / float64(time.Second)
-	stdDevDuration, err := time.ParseDuration(stdDev)
-	if err != nil {
-		panic(fmt.Sprintf("Parsing stdDev for NormalDelay in error = %s", err.Error()))
-	}
-	stdDevF := float64(stdDevDuration) / float64(time.Second)
-	maxDuration, err := time.ParseDuration(max)
+// parseDuration parses a duration parameter of the DSL element, recording an error if it can't be parsed.
+func (b *Builder) parseDuration(element, param, d string) (time.Duration, bool) {
+	dd, err := time.ParseDuration(d)
 	if err != nil {
-		panic(fmt.Sprintf("Parsing max for NormalDelay in error = %s", err.Error()))
+		b.Errorf("invalid %s for %s: %s", param, element, err.Error())
+		return 0, false
 	}
-	maxF := float64(maxDuration) / float64(time.Second)
-
-	// Calculate mu & sigma
-	a := 1 + (stdDevF*stdDevF)/math.Pow(meanF, 2)
-	// u := math.Log(meanF) - a/2
-	u := math.Log(meanF / math.Sqrt(a))
-	s := math.Sqrt(math.Log(a))
-	DecorateHandler(Waiter(nextWaitTimeSmoothedNormal(maxF, u, s)), NoopHandler)
+	return dd, true
 }
 
 // Waiter defines a generic waiter that will use the provided waitTime function to acquire the duration to wait.
 func Waiter(waitTime func() time.Duration) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		time.Sleep(waitTime())
+		d := waitTime()
+		time.Sleep(d)
+		addDelay(w, d)
 	})
 }
 
@@ -162,11 +152,17 @@ func (fd *fixedDelay) Wait() error {
 
 // Wait sleeps the current go routine for a random amount of time
 func (ud *uniformDelay) Wait() error {
-	time.Sleep(ud.min + time.Duration(rand.Intn(int(ud.max-ud.min))))
+	if ud.max == ud.min {
+		time.Sleep(ud.min)
+		return nil
+	}
+	time.Sleep(ud.min + time.Duration(rand.Int63n(int64(ud.max-ud.min))))
 	return nil
 }
 
 // ServeHTTP implemented to wait before passing the request off to the next handler.
 func (d *delayBase) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
 	d.waiter.Wait()
+	addDelay(w, time.Since(start))
 }
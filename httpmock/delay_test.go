@@ -7,15 +7,17 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/wcharczuk/go-chart"
 	"io"
-	"log"
 	"math"
 	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestFixedDelay(t *testing.T) {
-	currentMockHandler = NoopHandler
+	useTestBuilder(t, NoopHandler)
 	FixedDelay("100ms")
 
 	_, samples := runSamples()
@@ -43,7 +45,7 @@ func TestFixedDelay(t *testing.T) {
 }
 
 func TestUniformDelay(t *testing.T) {
-	currentMockHandler = NoopHandler
+	useTestBuilder(t, NoopHandler)
 	UniformDelay("100ms", "200ms")
 
 	_, samples := runSamples()
@@ -71,7 +73,7 @@ func TestUniformDelay(t *testing.T) {
 }
 
 func TestNormalDelay(t *testing.T) {
-	currentMockHandler = NoopHandler
+	useTestBuilder(t, NoopHandler)
 	NormalDelay("100ms", "20ms", "200ms")
 
 	timeSamples, samples := runSamples()
@@ -102,24 +104,135 @@ func TestNormalDelay(t *testing.T) {
 	sw := bytes.NewBuffer(make([]byte, 0, 512))
 	printHistogram(sw, buckets, histogram, 40)
 	t.Logf("\n%s", sw.String())
-	renderTimeSeries(timeSamples, samples, "./testdata/NormalDelayTest1.png")
+	renderTimeSeries(t, timeSamples, samples, "NormalDelayTest1.png")
+}
+
+func TestNormalDelayTruncated(t *testing.T) {
+	useTestBuilder(t, NoopHandler)
+	NormalDelay("20ms", "40ms", "60ms")
+
+	_, samples := runSamples()
+	population := stats.LoadRawData(samples)
+
+	max, err := population.Max()
+	assert.NoError(t, err)
+	assert.True(t, max < float64(70*time.Millisecond), "the longest delay was %s", time.Duration(max))
+
+	// Truncated at 0 and 60ms, the normal distribution with a mean of 20ms and a standard deviation of 40ms has a mean
+	// of 28ms.
+	mean, err := population.Mean()
+	assert.NoError(t, err)
+	assert.InDelta(t, 28*float64(time.Millisecond), mean, 5*float64(time.Millisecond))
+}
+
+func TestLogNormalDelay(t *testing.T) {
+	useTestBuilder(t, NoopHandler)
+	LogNormalDelay("100ms", 0.2, "500ms")
+
+	_, samples := runSamples()
+	assertPercentiles(t, samples, map[float64]time.Duration{
+		50:   100 * time.Millisecond,
+		85:   123 * time.Millisecond,
+		97.5: 148 * time.Millisecond,
+	}, 10*time.Millisecond)
+}
+
+func TestExponentialDelay(t *testing.T) {
+	useTestBuilder(t, NoopHandler)
+	ExponentialDelay("50ms", "")
+
+	_, samples := runSamples()
+	population := stats.LoadRawData(samples)
+	mean, err := population.Mean()
+	assert.NoError(t, err)
+	assert.InDelta(t, 50*float64(time.Millisecond), mean, 5*float64(time.Millisecond))
+	assertPercentiles(t, samples, map[float64]time.Duration{
+		50: 35 * time.Millisecond,
+		95: 150 * time.Millisecond,
+	}, 10*time.Millisecond)
+}
+
+func TestParetoDelay(t *testing.T) {
+	useTestBuilder(t, NoopHandler)
+	ParetoDelay("50ms", 3, "1s")
+
+	_, samples := runSamples()
+	population := stats.LoadRawData(samples)
+	min, err := population.Min()
+	assert.NoError(t, err)
+	assert.True(t, min >= float64(50*time.Millisecond), "the shortest delay was %s", time.Duration(min))
+	assertPercentiles(t, samples, map[float64]time.Duration{
+		50: 63 * time.Millisecond,
+		90: 108 * time.Millisecond,
+	}, 10*time.Millisecond)
+}
+
+func TestEmpiricalDelay(t *testing.T) {
+	useTestBuilder(t, NoopHandler)
+	EmpiricalDelay(map[float64]string{50: "100ms", 90: "150ms", 100: "200ms"})
+
+	_, samples := runSamples()
+	assertPercentiles(t, samples, map[float64]time.Duration{
+		25: 50 * time.Millisecond,
+		50: 100 * time.Millisecond,
+		70: 125 * time.Millisecond,
+		95: 175 * time.Millisecond,
+	}, 10*time.Millisecond)
+}
+
+func TestDistributionConfigErrors(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.NormalDelay("10ms", "-1ms", "")
+		b.LogNormalDelay("0s", 0.5, "")
+		b.LogNormalDelay("10ms", -1, "")
+		b.ExponentialDelay("10ms", "0s")
+		b.ParetoDelay("10ms", 0, "")
+		b.ParetoDelay("10ms", 2, "5ms")
+		b.EmpiricalDelay(nil)
+		b.EmpiricalDelay(map[float64]string{101: "1s"})
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 8) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "stdDev -1ms for NormalDelay must not be negative", errs[0].Message)
+		assert.Equal(t, "median 0s for LogNormalDelay must be greater than 0", errs[1].Message)
+		assert.Equal(t, "sigma -1 for LogNormalDelay must not be negative", errs[2].Message)
+		assert.Equal(t, "max 0s for ExponentialDelay must be greater than 0", errs[3].Message)
+		assert.Equal(t, "alpha 0 for ParetoDelay must be greater than 0", errs[4].Message)
+		assert.Equal(t, "min 10ms is greater than max 5ms for ParetoDelay", errs[5].Message)
+		assert.Equal(t, "EmpiricalDelay must have at least one percentile", errs[6].Message)
+		assert.Equal(t, "percentile 101 for EmpiricalDelay must be between 0 and 100", errs[7].Message)
+	}
+}
+
+// assertPercentiles asserts that the percentiles of the samples are within delta of the durations expected.
+func assertPercentiles(t *testing.T, samples []time.Duration, expected map[float64]time.Duration,
+	delta time.Duration) {
+	population := stats.LoadRawData(samples)
+	for p, d := range expected {
+		actual, err := population.Percentile(p)
+		assert.NoError(t, err)
+		assert.InDelta(t, float64(d), actual, float64(delta), "p%v was %s", p, time.Duration(actual))
+	}
 }
 
 func TestNormalSmoothedDelay(t *testing.T) {
-	currentMockHandler = NoopHandler
-	SmoothedNormalDelay("100ms", "20ms", "200ms")
+	useTestBuilder(t, NoopHandler)
+	SmoothedNormalDelay("100ms", "20ms", "200ms", 0.3)
 
 	timeSamples, durationSamples := runSamples()
 
 	population := stats.LoadRawData(durationSamples)
 
+	// Successive delays are correlated, so the 10000 samples are worth only 10000 * 0.3 / 1.7, about 1760, independent
+	// ones and the standard error of the mean and median is about 0.5ms.  The tolerances allow for 5 of those and for
+	// the time the sleeps overrun by.  TestSmoothedNormalAutocorrelation checks the distribution without the timing.
 	mean, err := population.Mean()
 	assert.NoError(t, err)
-	assert.InDelta(t, float64(100*time.Millisecond), mean, 1*float64(time.Millisecond))
+	assert.InDelta(t, float64(100*time.Millisecond), mean, 4*float64(time.Millisecond))
 
 	median, err := population.Median()
 	assert.NoError(t, err)
-	assert.InDelta(t, float64(100*time.Millisecond), median, 2*float64(time.Millisecond))
+	assert.InDelta(t, float64(100*time.Millisecond), median, 4*float64(time.Millisecond))
 
 	p95, err := population.Percentile(85.0)
 	assert.NoError(t, err)
@@ -138,10 +251,117 @@ func TestNormalSmoothedDelay(t *testing.T) {
 	sw := bytes.NewBuffer(make([]byte, 0, 512))
 	printHistogram(sw, buckets, histogram, 40)
 	t.Logf("\n%s", sw.String())
-	renderTimeSeries(timeSamples, durationSamples, "./testdata/SmooothedNormalDelayTest1.png")
+	renderTimeSeries(t, timeSamples, durationSamples, "SmoothedNormalDelayTest1.png")
+}
+
+func TestLoadDependentDelay(t *testing.T) {
+	var lock sync.Mutex
+	var seen []int
+	useTestBuilder(t, NoopHandler)
+	LoadDependentDelay(func(inFlight int) time.Duration {
+		lock.Lock()
+		defer lock.Unlock()
+		seen = append(seen, inFlight)
+		return 100 * time.Millisecond
+	})
+
+	runConcurrently(5)
+	sort.Ints(seen)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, seen)
+
+	runConcurrently(1)
+	assert.Equal(t, 1, seen[len(seen)-1])
+}
+
+func TestQueueingDelay(t *testing.T) {
+	useTestBuilder(t, NoopHandler)
+	QueueingDelay("100ms", 2)
+
+	durations := runConcurrently(5)
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	expected := []time.Duration{100, 100, 200, 200, 300}
+	for i, d := range durations {
+		assert.InDelta(t, float64(expected[i]*time.Millisecond), float64(d), float64(50*time.Millisecond),
+			"request %d took %s", i, d)
+	}
+}
+
+func TestLoadDependentDelayConfigErrors(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.LoadDependentDelay(nil)
+		b.QueueingDelay("soon", 1)
+		b.QueueingDelay("10ms", 0)
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 3) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "latency for LoadDependentDelay must not be nil", errs[0].Message)
+		assert.Contains(t, errs[1].Message, "invalid serviceTime for QueueingDelay: ")
+		assert.Equal(t, "capacity for QueueingDelay must be greater than 0 but is 0", errs[2].Message)
+	}
+}
+
+// runConcurrently serves n requests at once with the current handler and returns how long each took.
+func runConcurrently(n int) []time.Duration {
+	durations := make([]time.Duration, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			durations[i] = timeAction(func() {
+				CurrentHandler().ServeHTTP(nil, nil)
+			})
+		}(i)
+	}
+	wg.Wait()
+	return durations
+}
+
+func TestSmoothedNormalAutocorrelation(t *testing.T) {
+	for _, smoothing := range []float64{1, 0.3, 0.05} {
+		next := smoothedNormal(100, 20, 0, smoothing)
+		samples := make([]float64, 100000)
+		for i := range samples {
+			samples[i] = float64(next())
+		}
+		population := stats.LoadRawData(samples)
+		mean, _ := population.Mean()
+		stdDev, _ := population.StandardDeviation()
+		assert.InDelta(t, 100, mean, 3, "the mean with smoothing %v", smoothing)
+		assert.InDelta(t, 20, stdDev, 2, "the standard deviation with smoothing %v", smoothing)
+		assert.InDelta(t, 1-smoothing, lag1Correlation(samples, mean), 0.05, "the autocorrelation with smoothing %v",
+			smoothing)
+	}
+}
+
+// lag1Correlation returns the correlation of each sample with the next.
+func lag1Correlation(samples []float64, mean float64) float64 {
+	var covariance, variance float64
+	for i, x := range samples {
+		variance += (x - mean) * (x - mean)
+		if i > 0 {
+			covariance += (x - mean) * (samples[i-1] - mean)
+		}
+	}
+	return covariance / variance
+}
+
+func TestSmoothedNormalDelayConfigErrors(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.SmoothedNormalDelay("100ms", "20ms", "200ms", 0)
+		b.SmoothedNormalDelay("100ms", "20ms", "200ms", 1.5)
+		b.SmoothedNormalDelay("100ms", "-20ms", "200ms", 0.5)
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 3) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "smoothing 0 for SmoothedNormalDelay must be greater than 0 and at most 1", errs[0].Message)
+		assert.Equal(t, "smoothing 1.5 for SmoothedNormalDelay must be greater than 0 and at most 1", errs[1].Message)
+		assert.Equal(t, "stdDev -20ms for SmoothedNormalDelay must not be negative", errs[2].Message)
+	}
 }
 
-func renderTimeSeries(times []time.Time, durations []time.Duration, fileName string) {
+// renderTimeSeries charts the durations in the file named, in the test's temporary directory.
+func renderTimeSeries(t *testing.T, times []time.Time, durations []time.Duration, fileName string) {
 	durationFloats := make([]float64, len(durations))
 	for i, d := range durations {
 		durationFloats[i] = float64(d) / float64(time.Second)
@@ -160,9 +380,9 @@ func renderTimeSeries(times []time.Time, durations []time.Duration, fileName str
 		},
 	}
 
-	f, err := os.Create(fileName)
+	f, err := os.Create(filepath.Join(t.TempDir(), fileName))
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
 	defer f.Close()
 	graph.Render(chart.PNG, f)
@@ -186,7 +406,7 @@ func runSamples() ([]time.Time, []time.Duration) {
 		go func() {
 			for s := 0; s < numSamples/parallel; s++ {
 				duration := timeAction(func() {
-					currentMockHandler.ServeHTTP(nil, nil)
+					CurrentHandler().ServeHTTP(nil, nil)
 				})
 				sampleChan <- timedSample{time.Now(), duration}
 			}
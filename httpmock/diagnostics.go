@@ -0,0 +1,198 @@
+package httpmock
+
+import (
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NearMisses is the number of candidate endpoints listed when DiagnoseUnmatched is used.
+const NearMisses = 3
+
+type describedPredicate struct {
+	description string
+	predicate   predicate.Predicate
+}
+
+// Describe returns a predicate that accepts what the predicate given accepts.  The description is used when explaining
+// why a request did not match an endpoint, see DiagnoseUnmatched, e.g.
+//
+//    EndpointForCondition(AllOf(Describe("path is /orders", PathEquals("/orders")),
+//      Describe("header X-Tenant is acme", HeaderEquals("X-Tenant", "acme"))), func() {
+//      ...
+//    })
+//
+// A predicate without a description is named by the DSL element it was given to and where that was used.
+func Describe(description string, p predicate.Predicate) predicate.Predicate {
+	return &describedPredicate{description: description, predicate: p}
+}
+
+func (p *describedPredicate) Accept(v interface{}) bool {
+	return p.predicate.Accept(v)
+}
+
+func (p *describedPredicate) String() string {
+	return p.description
+}
+
+type allOf []predicate.Predicate
+
+// AllOf is like predicate.And, it accepts a value only if every predicate given accepts it, but the predicates are
+// explained separately when a request does not match the endpoint, see DiagnoseUnmatched.
+func AllOf(predicates ...predicate.Predicate) predicate.Predicate {
+	return allOf(predicates)
+}
+
+func (a allOf) Accept(v interface{}) bool {
+	for _, p := range a {
+		if !p.Accept(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// describe returns the description of the predicate.
+func describe(p predicate.Predicate) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return "a condition with no description"
+}
+
+// described returns the predicate with the description given for each of its conditions, see conditions, that has
+// none, so that a condition built from the predicates of go-http-matchers can be told apart from the others.
+func described(p predicate.Predicate, description string) predicate.Predicate {
+	if all, ok := p.(allOf); ok {
+		result := make(allOf, len(all))
+		for i, c := range all {
+			result[i] = described(c, description)
+		}
+		return result
+	}
+	if _, ok := p.(fmt.Stringer); ok {
+		return p
+	}
+	return Describe(description, p)
+}
+
+// conditions returns the conditions of the predicate, those combined by AllOf are explained separately.
+func conditions(p predicate.Predicate) []predicate.Predicate {
+	all, ok := p.(allOf)
+	if !ok {
+		return []predicate.Predicate{p}
+	}
+	result := make([]predicate.Predicate, 0, len(all))
+	for _, c := range all {
+		result = append(result, conditions(c)...)
+	}
+	return result
+}
+
+// DiagnoseUnmatched makes the mockery explain the requests that no endpoint matches.  Instead of an empty 404 the
+// response body, which is also logged, lists the endpoints that came closest to matching with the conditions each of
+// them failed, e.g.
+//
+//    No endpoint matched GET /orders/2
+//    Closest endpoints:
+//      /orders/1 defined at orders.yaml:endpoints[0]
+//        failed: path is "/orders/1"
+//
+// A Switch with no matching Case, e.g. an Endpoint with no Method for the request's method, explains itself in the
+// same way.  It should be called at the top level of the Mockery config function.
+func DiagnoseUnmatched() {
+	CurrentBuilder().DiagnoseUnmatched()
+}
+
+// DiagnoseUnmatched makes the mockery explain the requests that no endpoint matches.
+func (b *Builder) DiagnoseUnmatched() {
+	b.mockery.diagnose = true
+}
+
+type nearMiss struct {
+	info   EndpointInfo
+	failed []string
+	passed int
+}
+
+// diagnoseUnmatched returns the explanation of why none of the handlers matched the request.
+func (m *mockery) diagnoseUnmatched(handlers byPriority, request *http.Request, resetBody func()) string {
+	misses := make([]*nearMiss, 0, len(handlers))
+	for _, h := range handlers {
+		if m.servesMux(h) {
+			m.lock.RLock()
+			for _, e := range m.muxEndpoints {
+				misses = append(misses, &nearMiss{info: e.EndpointInfo,
+					failed: []string{fmt.Sprintf("path matches ServeMux pattern %q", e.Name)}})
+			}
+			m.lock.RUnlock()
+			continue
+		}
+		miss := &nearMiss{info: h.EndpointInfo}
+		for _, c := range conditions(h.predicate) {
+			// Every condition is given the whole body.
+			resetBody()
+			if c.Accept(request) {
+				miss.passed++
+			} else {
+				miss.failed = append(miss.failed, describe(c))
+			}
+		}
+		misses = append(misses, miss)
+	}
+	// The closest endpoints are those that failed the fewest conditions and, of those, passed the most.
+	sort.SliceStable(misses, func(i, j int) bool {
+		if len(misses[i].failed) != len(misses[j].failed) {
+			return len(misses[i].failed) < len(misses[j].failed)
+		}
+		return misses[i].passed > misses[j].passed
+	})
+	if len(misses) > NearMisses {
+		misses = misses[:NearMisses]
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "No endpoint matched %s %s\n", request.Method, request.URL.RequestURI())
+	if len(misses) == 0 {
+		buf.WriteString("There are no endpoints\n")
+		return buf.String()
+	}
+	buf.WriteString("Closest endpoints:\n")
+	for _, miss := range misses {
+		if miss.info.Name == miss.info.Location {
+			fmt.Fprintf(&buf, "  %s\n", miss.info.Name)
+		} else {
+			fmt.Fprintf(&buf, "  %s defined at %s\n", miss.info.Name, miss.info.Location)
+		}
+		for _, failed := range miss.failed {
+			fmt.Fprintf(&buf, "    failed: %s\n", failed)
+		}
+	}
+	return buf.String()
+}
+
+// diagnoseSwitch returns the explanation of why none of the cases of the switch matched the key.
+func diagnoseSwitch(scs *switchCaseSet, key interface{}) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "No case of the Switch matched %v\n", key)
+	descriptions := make([]string, len(scs.switchCases))
+	for i, sc := range scs.switchCases {
+		descriptions[i] = describe(sc.predicate)
+	}
+	if len(descriptions) > 0 {
+		fmt.Fprintf(&buf, "Cases:\n  %s\n", strings.Join(descriptions, "\n  "))
+	}
+	return buf.String()
+}
+
+// writeDiagnostic logs the explanation and writes it as the body of a 404 response.
+func writeDiagnostic(w http.ResponseWriter, diagnostic string) {
+	log.Print(diagnostic)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(diagnostic))
+}
@@ -0,0 +1,114 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/go-http-matchers/extractor"
+	. "github.com/bluesoftdev/go-http-matchers/predicate"
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiagnoseUnmatched(t *testing.T) {
+	mock := Mockery(func() {
+		DiagnoseUnmatched()
+		Endpoint("/foo", func() {
+			Method("GET", func() {
+				Respond(200)
+			})
+			Method("PUT", func() {
+				Respond(204)
+			})
+		})
+		EndpointPattern("^/orders/[0-9]+$", func() {
+			Respond(200)
+		})
+		DefinedAt("orders.yaml:endpoints[0]", func() {
+			EndpointForCondition(AllOf(
+				Describe(`path is "/orders"`, PathEquals("/orders")),
+				Describe("method is POST", MethodIs("POST")),
+				Describe(`header X-Tenant is "acme"`, HeaderEquals("X-Tenant", "acme"))), func() {
+				Respond(201)
+			})
+		})
+	})
+
+	request := httptest.NewRequest("POST", "/orders?page=2", nil)
+	request.Header.Set("X-Tenant", "other")
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, request)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `No endpoint matched POST /orders?page=2
+Closest endpoints:
+  orders.yaml:endpoints[0]
+    failed: header X-Tenant is "acme"
+  /foo defined at `+mock.Endpoints()[0].Location+`
+    failed: path matches ServeMux pattern "/foo"
+  ^/orders/[0-9]+$ defined at `+mock.Endpoints()[1].Location+`
+    failed: path matches "^/orders/[0-9]+$"
+`, w.Body.String())
+
+	status, body := serve(mock, "DELETE", "/foo")
+	assert.Equal(t, 404, status)
+	assert.Equal(t, "No case of the Switch matched DELETE\nCases:\n  method is GET\n  method is PUT\n", body)
+	assert.Equal(t, 404, mock.Requests()[1].Status)
+}
+
+func TestDiagnoseUnmatchedScenario(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.DiagnoseUnmatched()
+		b.Scenario("order", func() {
+			b.InState("SHIPPED", func() {
+				b.EndpointForCondition(PathEquals("/orders"), func() {
+					b.Respond(200)
+				})
+			})
+		})
+	})
+	_, body := serve(mock, "GET", "/orders")
+	assert.Contains(t, body, "    failed: scenario order is in state SHIPPED\n")
+	assert.NotContains(t, body, "a condition with no description")
+}
+
+func TestDiagnoseUnmatchedUndescribedConditions(t *testing.T) {
+	mock := Mockery(func() {
+		DiagnoseUnmatched()
+		DefinedAt("orders.yaml:endpoints[0]", func() {
+			EndpointForCondition(PathEquals("/orders"), func() {
+				Switch(ExtractQueryParameter("status"), func() {
+					Case(StringEquals("open"), func() {
+						Respond(200)
+					})
+				})
+			})
+		})
+	})
+
+	_, body := serve(mock, "GET", "/invoices")
+	assert.Equal(t, `No endpoint matched GET /invoices
+Closest endpoints:
+  orders.yaml:endpoints[0]
+    failed: the condition given to EndpointForCondition at orders.yaml:endpoints[0]
+`, body)
+	_, body = serve(mock, "GET", "/orders?status=closed")
+	assert.Equal(t, "No case of the Switch matched closed\nCases:\n"+
+		"  the condition given to Case at orders.yaml:endpoints[0]\n", body)
+}
+
+func TestUnmatchedWithoutDiagnostics(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/foo", func() {
+			b.Method("GET", func() {
+				b.Respond(200)
+			})
+		})
+	})
+	status, body := serve(mock, "GET", "/bar")
+	assert.Equal(t, 404, status)
+	assert.Empty(t, body)
+	status, body = serve(mock, "POST", "/foo")
+	assert.Equal(t, 404, status)
+	assert.Empty(t, body)
+}
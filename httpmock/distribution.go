@@ -0,0 +1,287 @@
+package httpmock
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// maxResamples is the number of times a truncated distribution is sampled for a value within its bounds before the
+// value is clamped to them.
+const maxResamples = 100
+
+// NormalDelay defines a delay whose distribution conforms to a Normal Distribution with the given mean and standard
+// deviation, truncated at 0 and the maximum: values outside them are drawn again.  All the durations are expressed in
+// a format compatible with time.ParseDuration, an empty max leaves the distribution unbounded above.
+func NormalDelay(mean, stdDev, max string) {
+	CurrentBuilder().NormalDelay(mean, stdDev, max)
+}
+
+// NormalDelay defines a delay whose distribution conforms to a Normal Distribution with the given mean and standard
+// deviation, truncated at 0 and the maximum.
+func (b *Builder) NormalDelay(mean, stdDev, max string) {
+	meanD, meanOk := b.parseDuration("NormalDelay", "mean", mean)
+	stdDevD, stdDevOk := b.parseDuration("NormalDelay", "stdDev", stdDev)
+	maxD, maxOk := b.parseMax("NormalDelay", max)
+	if !meanOk || !stdDevOk || !maxOk {
+		return
+	}
+	if stdDevD < 0 {
+		b.Errorf("stdDev %s for NormalDelay must not be negative", stdDev)
+		return
+	}
+	b.DecorateHandler(Waiter(truncated(maxD, func() float64 {
+		return float64(meanD) + float64(stdDevD)*rand.NormFloat64()
+	})), NoopHandler)
+}
+
+// SmoothedNormalDelay defines a delay that, like NormalDelay, is normally distributed with the given mean and
+// standard deviation and held between 0 and the maximum, but whose successive delays are correlated so that slow
+// responses come in bursts, as they do from real backends.  Each delay moves the previous one towards a fresh normal
+// sample by the smoothing factor, which is between 0 and 1: the smaller it is the longer the bursts last, with 1 the
+// delays are independent.  The spread of the delays is kept at the standard deviation whatever the smoothing, e.g.
+//
+//    SmoothedNormalDelay("100ms", "20ms", "200ms", 0.1)
+//
+func SmoothedNormalDelay(mean, stdDev, max string, smoothing float64) {
+	CurrentBuilder().SmoothedNormalDelay(mean, stdDev, max, smoothing)
+}
+
+// SmoothedNormalDelay defines a normally distributed delay whose successive delays are correlated, the smoothing
+// factor between 0 and 1 sets how quickly the delay changes.
+func (b *Builder) SmoothedNormalDelay(mean, stdDev, max string, smoothing float64) {
+	meanD, meanOk := b.parseDuration("SmoothedNormalDelay", "mean", mean)
+	stdDevD, stdDevOk := b.parseDuration("SmoothedNormalDelay", "stdDev", stdDev)
+	maxD, maxOk := b.parseMax("SmoothedNormalDelay", max)
+	if !meanOk || !stdDevOk || !maxOk {
+		return
+	}
+	if stdDevD < 0 {
+		b.Errorf("stdDev %s for SmoothedNormalDelay must not be negative", stdDev)
+		return
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		b.Errorf("smoothing %v for SmoothedNormalDelay must be greater than 0 and at most 1", smoothing)
+		return
+	}
+	b.DecorateHandler(Waiter(smoothedNormal(float64(meanD), float64(stdDevD), maxD, smoothing)), NoopHandler)
+}
+
+// smoothedNormal returns a function whose results follow an autoregressive process: each moves the last towards a
+// normal sample by the smoothing factor, with the sample's spread scaled so that the results have the standard
+// deviation given.  The state is updated with a compare and swap so that concurrent requests don't wait on each
+// other.  Unlike the other delays, which are truncated by drawing values outside the bounds again, the delays are
+// clamped to 0 and the maximum: drawing a value again would drop the correlation with the last one, and keeping it
+// from leaving the bounds would bias the process.  So the state is left unbounded and a burst that goes past the
+// maximum is served at the maximum, as a saturated backend would be.
+func smoothedNormal(mean, stdDev float64, max time.Duration, smoothing float64) func() time.Duration {
+	rho := 1 - smoothing
+	innovation := stdDev * math.Sqrt(1-rho*rho)
+	state := math.Float64bits(mean)
+	next := func() float64 {
+		for {
+			old := atomic.LoadUint64(&state)
+			x := mean + rho*(math.Float64frombits(old)-mean) + innovation*rand.NormFloat64()
+			if atomic.CompareAndSwapUint64(&state, old, math.Float64bits(x)) {
+				return x
+			}
+		}
+	}
+	upper := math.Inf(1)
+	if max > 0 {
+		upper = float64(max)
+	}
+	return func() time.Duration {
+		return time.Duration(math.Max(0, math.Min(upper, next())))
+	}
+}
+
+// LogNormalDelay defines a delay whose logarithm is normally distributed, like wiremock's lognormal delay
+// distribution.  The median is the median of the delays and sigma the standard deviation of their logarithm, the
+// larger it is the longer the tail.  The delays are truncated at the maximum, an empty max leaves them unbounded, e.g.
+//
+//    LogNormalDelay("80ms", 0.4, "2s")
+//
+func LogNormalDelay(median string, sigma float64, max string) {
+	CurrentBuilder().LogNormalDelay(median, sigma, max)
+}
+
+// LogNormalDelay defines a delay whose logarithm is normally distributed with the median given and the standard
+// deviation sigma.
+func (b *Builder) LogNormalDelay(median string, sigma float64, max string) {
+	medianD, medianOk := b.parseDuration("LogNormalDelay", "median", median)
+	maxD, maxOk := b.parseMax("LogNormalDelay", max)
+	if !medianOk || !maxOk {
+		return
+	}
+	if medianD <= 0 {
+		b.Errorf("median %s for LogNormalDelay must be greater than 0", median)
+		return
+	}
+	if sigma < 0 {
+		b.Errorf("sigma %v for LogNormalDelay must not be negative", sigma)
+		return
+	}
+	mu := math.Log(float64(medianD))
+	b.DecorateHandler(Waiter(truncated(maxD, func() float64 {
+		return math.Exp(mu + sigma*rand.NormFloat64())
+	})), NoopHandler)
+}
+
+// ExponentialDelay defines a delay that is exponentially distributed with the mean given, as are the times between
+// independent events.  The delays are truncated at the maximum, an empty max leaves them unbounded.
+func ExponentialDelay(mean, max string) {
+	CurrentBuilder().ExponentialDelay(mean, max)
+}
+
+// ExponentialDelay defines a delay that is exponentially distributed with the mean given.
+func (b *Builder) ExponentialDelay(mean, max string) {
+	meanD, meanOk := b.parseDuration("ExponentialDelay", "mean", mean)
+	maxD, maxOk := b.parseMax("ExponentialDelay", max)
+	if !meanOk || !maxOk {
+		return
+	}
+	if meanD <= 0 {
+		b.Errorf("mean %s for ExponentialDelay must be greater than 0", mean)
+		return
+	}
+	b.DecorateHandler(Waiter(truncated(maxD, func() float64 {
+		return float64(meanD) * rand.ExpFloat64()
+	})), NoopHandler)
+}
+
+// ParetoDelay defines a delay that follows a Pareto distribution, no shorter than min and with a heavy tail whose
+// weight is set by alpha: the smaller alpha is the more often very long delays occur.  The delays are truncated at
+// the maximum, an empty max leaves them unbounded, e.g.
+//
+//    ParetoDelay("20ms", 2.5, "10s")
+//
+func ParetoDelay(min string, alpha float64, max string) {
+	CurrentBuilder().ParetoDelay(min, alpha, max)
+}
+
+// ParetoDelay defines a delay that follows a Pareto distribution with the minimum min and shape alpha.
+func (b *Builder) ParetoDelay(min string, alpha float64, max string) {
+	minD, minOk := b.parseDuration("ParetoDelay", "min", min)
+	maxD, maxOk := b.parseMax("ParetoDelay", max)
+	if !minOk || !maxOk {
+		return
+	}
+	if minD <= 0 {
+		b.Errorf("min %s for ParetoDelay must be greater than 0", min)
+		return
+	}
+	if alpha <= 0 {
+		b.Errorf("alpha %v for ParetoDelay must be greater than 0", alpha)
+		return
+	}
+	if maxD != 0 && maxD < minD {
+		b.Errorf("min %s is greater than max %s for ParetoDelay", min, max)
+		return
+	}
+	b.DecorateHandler(Waiter(truncated(maxD, func() float64 {
+		return float64(minD) / math.Pow(1-rand.Float64(), 1/alpha)
+	})), NoopHandler)
+}
+
+type percentilePoint struct {
+	percentile float64
+	delay      time.Duration
+}
+
+// EmpiricalDelay defines a delay that follows a distribution measured elsewhere, given as a table of percentiles,
+// from 0 to 100, and the delay at each.  Delays between the percentiles of the table are interpolated linearly, below
+// the lowest percentile they are interpolated from no delay and above the highest they are the delay of the highest,
+// so include the 100th percentile to shape the tail, e.g.
+//
+//    EmpiricalDelay(map[float64]string{50: "20ms", 90: "80ms", 99: "300ms", 100: "1s"})
+//
+func EmpiricalDelay(percentiles map[float64]string) {
+	CurrentBuilder().EmpiricalDelay(percentiles)
+}
+
+// EmpiricalDelay defines a delay that follows a distribution given as a table of percentiles and the delay at each.
+func (b *Builder) EmpiricalDelay(percentiles map[float64]string) {
+	if len(percentiles) == 0 {
+		b.Errorf("EmpiricalDelay must have at least one percentile")
+		return
+	}
+	points := make([]percentilePoint, 0, len(percentiles)+1)
+	ok := true
+	for p, d := range percentiles {
+		if p < 0 || p > 100 {
+			b.Errorf("percentile %v for EmpiricalDelay must be between 0 and 100", p)
+			ok = false
+			continue
+		}
+		dd, dOk := b.parseDuration("EmpiricalDelay", fmt.Sprintf("delay at p%v", p), d)
+		ok = ok && dOk
+		points = append(points, percentilePoint{p, dd})
+	}
+	if !ok {
+		return
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].percentile < points[j].percentile })
+	for i := 1; i < len(points); i++ {
+		if points[i].delay < points[i-1].delay {
+			b.Errorf("delay %s at p%v is less than the delay %s at p%v for EmpiricalDelay", points[i].delay,
+				points[i].percentile, points[i-1].delay, points[i-1].percentile)
+			return
+		}
+	}
+	if points[0].percentile > 0 {
+		points = append([]percentilePoint{{0, 0}}, points...)
+	}
+	b.DecorateHandler(Waiter(func() time.Duration {
+		return interpolatePercentile(points, 100*rand.Float64())
+	}), NoopHandler)
+}
+
+// interpolatePercentile returns the delay at the percentile p of the sorted table of points.
+func interpolatePercentile(points []percentilePoint, p float64) time.Duration {
+	i := sort.Search(len(points), func(i int) bool { return points[i].percentile >= p })
+	if i == len(points) {
+		return points[len(points)-1].delay
+	}
+	if i == 0 || points[i].percentile == p {
+		return points[i].delay
+	}
+	lower, upper := points[i-1], points[i]
+	fraction := (p - lower.percentile) / (upper.percentile - lower.percentile)
+	return lower.delay + time.Duration(fraction*float64(upper.delay-lower.delay))
+}
+
+// truncated returns a function that samples the distribution, in nanoseconds, until the value is between 0 and max.
+// A max of 0 leaves the distribution unbounded above.
+func truncated(max time.Duration, sample func() float64) func() time.Duration {
+	upper := math.Inf(1)
+	if max > 0 {
+		upper = float64(max)
+	}
+	return func() time.Duration {
+		var x float64
+		for i := 0; i < maxResamples; i++ {
+			x = sample()
+			if x >= 0 && x <= upper {
+				return time.Duration(x)
+			}
+		}
+		return time.Duration(math.Max(0, math.Min(upper, x)))
+	}
+}
+
+// parseMax parses the optional max parameter of a delay element, an empty max is returned as 0.
+func (b *Builder) parseMax(element, max string) (time.Duration, bool) {
+	if max == "" {
+		return 0, true
+	}
+	d, ok := b.parseDuration(element, "max", max)
+	if ok && d <= 0 {
+		b.Errorf("max %s for %s must be greater than 0", max, element)
+		return 0, false
+	}
+	return d, ok
+}
@@ -1,18 +1,46 @@
 package httpmock
 
 import (
+	"fmt"
 	"github.com/bluesoftdev/go-http-matchers/extractor"
 	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"net/http"
 	"regexp"
 )
 
 // Endpoint defines an endpoint that uses the http.ServeMux to dispatch requests.  The content of the configureFunc
 // should be Method elements which may contain
 func Endpoint(url string, configureFunc func()) {
-	outerCurrentMockHandler := currentMockHandler
-	Switch(extractor.ExtractMethod(), configureFunc)
-	currentMockery.Handle(url, currentMockHandler)
-	currentMockHandler = outerCurrentMockHandler
+	CurrentBuilder().endpoint(url, callerLocation(1), configureFunc)
+}
+
+// Endpoint defines an endpoint that uses the http.ServeMux to dispatch requests.
+func (b *Builder) Endpoint(url string, configureFunc func()) {
+	b.endpoint(url, callerLocation(1), configureFunc)
+}
+
+func (b *Builder) endpoint(url, location string, configureFunc func()) {
+	location = b.definedAt(location)
+	outerCurrentMockHandler := b.handler
+	outerScope := b.scope
+	outerTransitions := b.transitions
+	var transitions []scenarioTransition
+	b.scope, b.transitions = url, &transitions
+	b.switchOn(extractor.ExtractMethod(), true, configureFunc)
+	b.scope, b.transitions = outerScope, outerTransitions
+	if b.condition == nil && !b.isolated && len(transitions) == 0 {
+		b.handle(url, location, b.handler)
+	} else {
+		// The endpoint is conditional, e.g. on a scenario state, so it can't share the mockery's ServeMux.
+		mux := http.NewServeMux()
+		mux.Handle(url, b.handler)
+		b.handleForCondition(EndpointInfo{Name: url, Priority: DefaultPriority, Location: location},
+			b.and(Describe(fmt.Sprintf("path matches ServeMux pattern %q", url), predicate.PredicateFunc(func(r interface{}) bool {
+				_, p := mux.Handler(r.(*http.Request))
+				return p != ""
+			}))), mux, transitions)
+	}
+	b.handler = outerCurrentMockHandler
 }
 
 // DefaultPriority is the default priority for endpoint consideration.
@@ -20,23 +48,62 @@ const DefaultPriority = 100
 
 // EndpointPattern creates an endpoint that is selected by comparing the URL path with the pattern provided.
 func EndpointPattern(urlPattern string, configFunc func()) {
-	pathRegex := regexp.MustCompile(urlPattern)
-	EndpointForCondition(predicate.PathMatches(pathRegex), configFunc)
+	CurrentBuilder().endpointPattern(urlPattern, callerLocation(1), configFunc)
 }
 
-// EndpointForCondition creates an endpoint that is selected by the predicate passed.
+// EndpointPattern creates an endpoint that is selected by comparing the URL path with the pattern provided.
+func (b *Builder) EndpointPattern(urlPattern string, configFunc func()) {
+	b.endpointPattern(urlPattern, callerLocation(1), configFunc)
+}
+
+func (b *Builder) endpointPattern(urlPattern, location string, configFunc func()) {
+	pathRegex, err := regexp.Compile(urlPattern)
+	if err != nil {
+		b.Errorf("invalid pattern %q for EndpointPattern: %s", urlPattern, err.Error())
+		return
+	}
+	b.endpointForCondition(urlPattern, location, DefaultPriority,
+		Describe(fmt.Sprintf("path matches %q", urlPattern), predicate.PathMatches(pathRegex)), configFunc)
+}
+
+// EndpointForCondition creates an endpoint that is selected by the predicate passed.  In the request journal the
+// endpoint is named by the location in the source where it was defined.
 func EndpointForCondition(predicate predicate.Predicate, configFunc func()) {
-	outerCurrentMockHandler := currentMockHandler
-	configFunc()
-	currentMockery.HandleForCondition(DefaultPriority, predicate, currentMockHandler)
-	currentMockHandler = outerCurrentMockHandler
+	CurrentBuilder().endpointForCondition("", callerLocation(1), DefaultPriority, predicate, configFunc)
+}
+
+// EndpointForCondition creates an endpoint that is selected by the predicate passed.
+func (b *Builder) EndpointForCondition(predicate predicate.Predicate, configFunc func()) {
+	b.endpointForCondition("", callerLocation(1), DefaultPriority, predicate, configFunc)
 }
 
 // EndpointForConditionWithPriority defines an endpoint that is selected by the predicate given with the priority
 // provided.
 func EndpointForConditionWithPriority(priority int, predicate predicate.Predicate, configFunc func()) {
-	outerCurrentMockHandler := currentMockHandler
+	CurrentBuilder().endpointForCondition("", callerLocation(1), priority, predicate, configFunc)
+}
+
+// EndpointForConditionWithPriority defines an endpoint that is selected by the predicate given with the priority
+// provided.
+func (b *Builder) EndpointForConditionWithPriority(priority int, predicate predicate.Predicate, configFunc func()) {
+	b.endpointForCondition("", callerLocation(1), priority, predicate, configFunc)
+}
+
+// endpointForCondition defines an endpoint, if the name is empty the endpoint is named by its location.
+func (b *Builder) endpointForCondition(name, location string, priority int, predicate predicate.Predicate,
+	configFunc func()) {
+	location = b.definedAt(location)
+	if name == "" {
+		name = location
+	}
+	outerCurrentMockHandler := b.handler
+	outerScope := b.scope
+	outerTransitions := b.transitions
+	var transitions []scenarioTransition
+	b.scope, b.transitions = name, &transitions
 	configFunc()
-	currentMockery.HandleForCondition(priority, predicate, currentMockHandler)
-	currentMockHandler = outerCurrentMockHandler
+	b.scope, b.transitions = outerScope, outerTransitions
+	b.handleForCondition(EndpointInfo{Name: name, Priority: priority, Location: location},
+		b.and(described(predicate, "the condition given to EndpointForCondition at "+location)), b.handler, transitions)
+	b.handler = outerCurrentMockHandler
 }
@@ -1,11 +1,13 @@
 package httpmock
 
 import (
+	"fmt"
 	"github.com/bluesoftdev/go-http-matchers/predicate"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 )
 
@@ -179,3 +181,190 @@ func TestEndpointForConditionWithPriority(t *testing.T) {
 	// Test the result.
 	assert.Equal(t, 200, response.StatusCode)
 }
+
+func TestEndpoints(t *testing.T) {
+	mockery := Mockery(func() {
+		EndpointForConditionWithPriority(1, predicate.True(), func() {
+			Respond(200)
+		})
+		Endpoint("/foo", func() {})
+		EndpointPattern("/bar/.*", func() {})
+		Endpoint("/snafu", func() {})
+		DefinedAt("somewhere", func() {
+			EndpointForCondition(predicate.False(), func() {})
+		})
+	})
+
+	endpoints := mockery.Endpoints()
+	if assert.Len(t, endpoints, 5) {
+		assert.Equal(t, 1, endpoints[0].Priority)
+		assert.Contains(t, endpoints[0].Name, "endpoint_test.go")
+		assert.Equal(t, endpoints[0].Name, endpoints[0].Location)
+		assert.Equal(t, "/foo", endpoints[1].Name)
+		assert.Equal(t, "/snafu", endpoints[2].Name)
+		assert.Equal(t, "/bar/.*", endpoints[3].Name)
+		assert.Contains(t, endpoints[3].Location, "endpoint_test.go")
+		assert.Equal(t, "somewhere", endpoints[4].Name)
+		assert.Equal(t, "somewhere", endpoints[4].Location)
+		for _, e := range endpoints {
+			assert.NotEmpty(t, e.ID)
+		}
+	}
+}
+
+func serveStatus(handler http.Handler, path string) int {
+	mockWriter := httptest.NewRecorder()
+	handler.ServeHTTP(mockWriter, httptest.NewRequest("GET", path, nil))
+	return mockWriter.Code
+}
+
+func TestRuntimeEndpoints(t *testing.T) {
+	mockery := Mockery(func() {
+		Endpoint("/foo", func() {
+			Method("GET", func() {
+				Respond(200)
+			})
+		})
+		EndpointPattern("/bar", func() {
+			Respond(200)
+		})
+	})
+	initial := mockery.Endpoints()
+
+	ids, err := mockery.AddEndpoints(func(b *Builder) {
+		b.Endpoint("/added", func() {
+			b.Method("GET", func() {
+				b.Respond(201)
+			})
+		})
+		b.EndpointForConditionWithPriority(1, predicate.PathEquals("/bar"), func() {
+			b.Respond(202)
+		})
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 2)
+	assert.Len(t, mockery.Endpoints(), 4)
+	assert.Equal(t, ids[1], mockery.Endpoints()[0].ID)
+	assert.Equal(t, 201, serveStatus(mockery, "/added"))
+	assert.Equal(t, 202, serveStatus(mockery, "/bar"))
+
+	assert.NoError(t, mockery.SetEndpoint(ids[1], func(b *Builder) {
+		b.EndpointForConditionWithPriority(1, predicate.PathEquals("/bar"), func() {
+			b.Respond(203)
+		})
+	}))
+	assert.Len(t, mockery.Endpoints(), 4)
+	assert.Equal(t, 203, serveStatus(mockery, "/bar"))
+	assert.Error(t, mockery.SetEndpoint(ids[1], func(b *Builder) {}))
+
+	assert.NoError(t, mockery.RemoveEndpoint(ids[1]))
+	assert.Error(t, mockery.RemoveEndpoint(ids[1]))
+	assert.Equal(t, 200, serveStatus(mockery, "/bar"))
+
+	assert.NoError(t, mockery.RemoveEndpoint(initial[0].ID))
+	assert.Equal(t, 404, serveStatus(mockery, "/foo"))
+	assert.Len(t, mockery.Endpoints(), 2)
+
+	mockery.Reset()
+	assert.Equal(t, initial, mockery.Endpoints())
+	assert.Equal(t, 200, serveStatus(mockery, "/foo"))
+	assert.Equal(t, 404, serveStatus(mockery, "/added"))
+}
+
+func TestRemoveEndpointFallsBackToLessSpecificPattern(t *testing.T) {
+	mockery := Mockery(func() {
+		Endpoint("/", func() {
+			Method("GET", func() {
+				Respond(200)
+			})
+		})
+		Endpoint("/orders/", func() {
+			Method("GET", func() {
+				Respond(201)
+			})
+		})
+	})
+	assert.Equal(t, 201, serveStatus(mockery, "/orders/1"))
+
+	assert.NoError(t, mockery.RemoveEndpoint(mockery.Endpoints()[1].ID))
+	assert.Equal(t, 200, serveStatus(mockery, "/orders/1"))
+	assert.Len(t, mockery.Endpoints(), 1)
+
+	assert.NoError(t, mockery.RemoveEndpoint(mockery.Endpoints()[0].ID))
+	assert.Equal(t, 404, serveStatus(mockery, "/orders/1"))
+
+	mockery.Reset()
+	assert.Equal(t, 201, serveStatus(mockery, "/orders/1"))
+	assert.Len(t, mockery.Endpoints(), 2)
+}
+
+func TestReplaceEndpoints(t *testing.T) {
+	mockery := Mockery(func() {
+		Endpoint("/foo", func() {
+			Method("GET", func() {
+				Respond(200)
+			})
+		})
+		EndpointPattern("/bar", func() {
+			Respond(200)
+		})
+	})
+	initial := mockery.Endpoints()
+
+	ids, err := mockery.ReplaceEndpoints([]string{initial[0].ID, initial[1].ID, "unknown"}, func(b *Builder) {
+		b.EndpointPattern("/foo", func() {
+			b.Respond(201)
+		})
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 1)
+	assert.Len(t, mockery.Endpoints(), 1)
+	assert.Equal(t, ids[0], mockery.Endpoints()[0].ID)
+	assert.Equal(t, 201, serveStatus(mockery, "/foo"))
+	assert.Equal(t, 404, serveStatus(mockery, "/bar"))
+
+	_, err = mockery.ReplaceEndpoints(ids, func(b *Builder) {
+		b.EndpointPattern("/foo", func() {
+			b.FixedDelay("not a duration")
+			b.Respond(202)
+		})
+	})
+	assert.IsType(t, ConfigErrors{}, err)
+	assert.Len(t, mockery.Endpoints(), 1)
+	assert.Equal(t, 201, serveStatus(mockery, "/foo"))
+}
+
+func TestRuntimeEndpointsConcurrently(t *testing.T) {
+	mockery := Mockery(func() {
+		Endpoint("/foo", func() {
+			Method("GET", func() {
+				Respond(200)
+			})
+		})
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/added/%d", i)
+			ids, err := mockery.AddEndpoints(func(b *Builder) {
+				b.EndpointForCondition(predicate.PathEquals(path), func() {
+					b.Respond(201)
+				})
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 201, serveStatus(mockery, path))
+			assert.NoError(t, mockery.RemoveEndpoint(ids[0]))
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, 200, serveStatus(mockery, "/foo"))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, mockery.Endpoints(), 1)
+}
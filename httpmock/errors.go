@@ -0,0 +1,101 @@
+package httpmock
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ConfigError is a problem found while configuring a mockery, e.g. a duration that can't be parsed.
+type ConfigError struct {
+	// Location is where the DSL element with the problem was used, in the form recorded by LogLocation, or the
+	// location given to DefinedAt.
+	Location string
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Location, e.Message)
+}
+
+// ConfigErrors is the error returned by MockeryE and NewE, it lists every problem found in the configuration.
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Errorf records a configuration error for the DSL element being applied.  The error is located at the caller of the
+// DSL element, or at the location given to DefinedAt.  Configuration continues so that every error is reported, the
+// element with the error should not change the mock.
+func (b *Builder) Errorf(format string, args ...interface{}) {
+	b.errors = append(b.errors, &ConfigError{Location: b.definedAt(dslLocation()), Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns the configuration errors recorded so far as ConfigErrors, or nil if there are none.
+func (b *Builder) Err() error {
+	if len(b.errors) == 0 {
+		return nil
+	}
+	return append(ConfigErrors(nil), b.errors...)
+}
+
+// configure calls the configFunc, a panic is recorded as a configuration error, located where the DSL element that
+// panicked was used, and ends the configuration.  A panic with ConfigErrors, e.g. from a Mockery used within the
+// configFunc, records them as they are.
+func (b *Builder) configure(configFunc func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if errs, ok := r.(ConfigErrors); ok {
+				b.errors = append(b.errors, errs...)
+				return
+			}
+			b.errors = append(b.errors, &ConfigError{Location: b.definedAt(dslLocation()), Message: fmt.Sprint(r)})
+		}
+	}()
+	configFunc()
+}
+
+// dslPackage is the import path of this package, the DSL elements of its sub packages, e.g. wiremock, are located at
+// their callers too.
+var dslPackage = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+strings.Index(name[slash:], ".")]
+}()
+
+var goRoot = filepath.ToSlash(runtime.GOROOT()) + "/src/"
+
+// isLibraryFrame returns true if the frame is in the code of the DSL, the go runtime, the standard library or a
+// dependency, rather than in the code configuring the mock.
+func isLibraryFrame(frame runtime.Frame) bool {
+	file := filepath.ToSlash(frame.File)
+	if strings.HasPrefix(frame.Function, dslPackage+".") || strings.HasPrefix(frame.Function, dslPackage+"/") {
+		return !strings.HasSuffix(file, "_test.go")
+	}
+	return strings.HasPrefix(frame.Function, "runtime.") || strings.HasPrefix(file, goRoot) ||
+		strings.Contains(file, "/pkg/mod/")
+}
+
+// dslLocation returns the location of the code that applied the DSL element being configured, that is the innermost
+// caller that is not library code, in the form recorded by LogLocation.
+func dslLocation() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !isLibraryFrame(frame) {
+			return fmt.Sprintf("%s:%d(%s)", frame.File, frame.Line, frame.Function)
+		}
+		if !more {
+			return "Unknown"
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/go-http-matchers/extractor"
+	. "github.com/bluesoftdev/go-http-matchers/predicate"
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"testing"
+)
+
+// nextLine returns the line number of the line following the call.
+func nextLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line + 1
+}
+
+func assertConfigErrors(t *testing.T, err error, lines []int, messages []string) {
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, len(messages)) {
+		for i, e := range err.(ConfigErrors) {
+			assert.Contains(t, e.Location, fmt.Sprintf("errors_test.go:%d(", lines[i]))
+			assert.Contains(t, e.Message, messages[i])
+		}
+	}
+}
+
+func TestMockeryE(t *testing.T) {
+	lines := make([]int, 0, 5)
+	mock, err := MockeryE(func() {
+		Endpoint("/foo", func() {
+			Method("GET", func() {
+				RespondWithString(200, "foo")
+				lines = append(lines, nextLine())
+				FixedDelay("10 seconds")
+				lines = append(lines, nextLine())
+				UniformDelay("50ms", "10ms")
+			})
+		})
+		lines = append(lines, nextLine())
+		EndpointPattern("/bar/[0-9", func() {
+			Respond(200)
+		})
+		lines = append(lines, nextLine())
+		Case(PathEquals("/baz"), func() {
+			Respond(200)
+		})
+		lines = append(lines, nextLine())
+		RespondWithTemplate(200, "{{.Request.Method")
+	})
+	assert.Nil(t, mock)
+	assertConfigErrors(t, err, lines, []string{
+		`invalid delay for FixedDelay: time: unknown unit " seconds" in duration "10 seconds"`,
+		"min 50ms is greater than max 10ms for UniformDelay",
+		`invalid pattern "/bar/[0-9" for EndpointPattern: `,
+		"Case must be used within a Switch",
+		"invalid template body: ",
+	})
+}
+
+func TestMockeryEPanic(t *testing.T) {
+	lines := make([]int, 0, 2)
+	_, err := MockeryE(func() {
+		lines = append(lines, nextLine())
+		FixedDelay("soon")
+		Endpoint("/foo", func() {
+			Method("POST", func() {
+				Switch(ExtractMethod(), func() {
+					lines = append(lines, nextLine())
+					Case(BodyEqualsJSON("{"), func() {
+						Respond(200)
+					})
+				})
+			})
+		})
+		RespondWithString(200, "not reached")
+		lines = append(lines, nextLine())
+		FixedDelay("later")
+	})
+	assertConfigErrors(t, err, lines, []string{"invalid delay for FixedDelay", "Parsing JSON for BodyEqualsJSON"})
+}
+
+func TestNewE(t *testing.T) {
+	mock, err := NewE(func(b *Builder) {
+		b.Endpoint("/foo", func() {
+			b.Method("GET", func() {
+				b.RespondWithString(200, "foo")
+			})
+		})
+	})
+	assert.NoError(t, err)
+	status, body := serve(mock, "GET", "/foo")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "foo", body)
+
+	_, err = NewE(func(b *Builder) {
+		b.DefinedAt("stubs/foo.json", func() {
+			b.TransitionTo("SHIPPED")
+			b.Default(func() {})
+		})
+	})
+	assert.Equal(t, ConfigErrors{
+		{Location: "stubs/foo.json", Message: "TransitionTo must be used within a Scenario"},
+		{Location: "stubs/foo.json", Message: "Default must be used within a Switch"},
+	}, err)
+	assert.Equal(t, "stubs/foo.json: TransitionTo must be used within a Scenario\n"+
+		"stubs/foo.json: Default must be used within a Switch", err.Error())
+}
+
+func TestMockeryPanicsWithConfigErrors(t *testing.T) {
+	defer func() {
+		err := recover()
+		if assert.IsType(t, ConfigErrors{}, err) {
+			assert.Equal(t, "Method must be used within an Endpoint", err.(ConfigErrors)[0].Message)
+		}
+	}()
+	Mockery(func() {
+		Method("GET", func() {})
+	})
+}
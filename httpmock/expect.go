@@ -0,0 +1,187 @@
+package httpmock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxExpectationRequests is the number of requests an expectation remembers to show when it is not met.
+const maxExpectationRequests = 10
+
+// Expectation is the number of calls a response is expected to get, see Expect.
+type Expectation struct {
+	min, max int
+	// unbounded is true if there is no max, as for AtLeast.
+	unbounded   bool
+	description string
+}
+
+// Times expects exactly n calls.
+func Times(n int) Expectation {
+	return Expectation{min: n, max: n, description: fmt.Sprintf("exactly %d call(s)", n)}
+}
+
+// AtLeast expects n calls or more.
+func AtLeast(n int) Expectation {
+	return Expectation{min: n, unbounded: true, description: fmt.Sprintf("at least %d call(s)", n)}
+}
+
+// AtMost expects n calls or fewer.
+func AtMost(n int) Expectation {
+	return Expectation{min: 0, max: n, description: fmt.Sprintf("at most %d call(s)", n)}
+}
+
+// Never expects no calls.
+func Never() Expectation {
+	return Expectation{min: 0, max: 0, description: "no calls"}
+}
+
+func (e Expectation) met(calls int) bool {
+	return calls >= e.min && (e.unbounded || calls <= e.max)
+}
+
+type expectation struct {
+	Expectation
+	name string
+	// endpoint is the id of the endpoint the expectation is for, its expectations are dropped when it is removed.
+	endpoint string
+
+	lock     sync.Mutex
+	calls    int
+	requests []string
+}
+
+// Expect declares how many calls the response being defined is expected to get, it is checked by the mock's
+// VerifyExpectations and AssertExpectations.  It may be used anywhere a response is defined, e.g. within a Method or
+// a Case, and counts the requests that get that response.  The expectations of an endpoint are registered only if the
+// configuration it is part of succeeds, and are dropped when the endpoint is removed or replaced, e.g.
+//
+//    Endpoint("/orders", func() {
+//      Method("POST", func() {
+//        Expect(Times(1))
+//        Respond(201)
+//      })
+//      Method("DELETE", func() {
+//        Expect(Never())
+//        Respond(204)
+//      })
+//    })
+//
+func Expect(e Expectation) {
+	CurrentBuilder().Expect(e)
+}
+
+// Expect declares how many calls the response being defined is expected to get.
+func (b *Builder) Expect(e Expectation) {
+	if e.min < 0 || e.max < 0 {
+		b.Errorf("the number of calls for Expect must not be negative")
+		return
+	}
+	ex := &expectation{Expectation: e, name: b.definedAt(dslLocation())}
+	if b.scope != "" {
+		ex.name = fmt.Sprintf("%s [defined at %s]", b.scope, ex.name)
+	}
+	b.expectations = append(b.expectations, ex)
+	b.DecorateHandlerBefore(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		ex.record(request)
+	}))
+}
+
+func (ex *expectation) record(request *http.Request) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.calls++
+	if len(ex.requests) < maxExpectationRequests {
+		ex.requests = append(ex.requests, request.Method+" "+request.URL.RequestURI())
+	}
+}
+
+func (ex *expectation) reset() {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	ex.calls = 0
+	ex.requests = nil
+}
+
+// unmet describes the expectation and the requests it got if it was not met, it returns "" if it was.
+func (ex *expectation) unmet() string {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	if ex.met(ex.calls) {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "  %s: expected %s but got %d", ex.name, ex.description, ex.calls)
+	for _, r := range ex.requests {
+		fmt.Fprintf(&sb, "\n    %s", r)
+	}
+	if ex.calls > len(ex.requests) {
+		fmt.Fprintf(&sb, "\n    ... and %d more", ex.calls-len(ex.requests))
+	}
+	return sb.String()
+}
+
+// claimExpectations assigns the expectations staged since the last endpoint was defined to the endpoint with the id.
+func (b *Builder) claimExpectations(id string) {
+	for _, ex := range b.expectations {
+		if ex.endpoint == "" {
+			ex.endpoint = id
+		}
+	}
+}
+
+// addExpectations registers the expectations staged by the builder, once its configuration has succeeded, so that
+// they are checked by VerifyExpectations.
+func (m *mockery) addExpectations(b *Builder) {
+	m.expectationLock.Lock()
+	defer m.expectationLock.Unlock()
+	m.expectations = append(append(make([]*expectation, 0, len(m.expectations)+len(b.expectations)),
+		m.expectations...), b.expectations...)
+}
+
+// dropExpectations discards the expectations of the endpoint with the id, which has been removed.
+func (m *mockery) dropExpectations(id string) {
+	m.expectationLock.Lock()
+	defer m.expectationLock.Unlock()
+	kept := make([]*expectation, 0, len(m.expectations))
+	for _, ex := range m.expectations {
+		if ex.endpoint != id {
+			kept = append(kept, ex)
+		}
+	}
+	m.expectations = kept
+}
+
+func (m *mockery) VerifyExpectations() error {
+	m.expectationLock.Lock()
+	defer m.expectationLock.Unlock()
+	var unmet []string
+	for _, ex := range m.expectations {
+		if s := ex.unmet(); s != "" {
+			unmet = append(unmet, s)
+		}
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d expectation(s) not met:\n%s", len(unmet), strings.Join(unmet, "\n"))
+}
+
+func (m *mockery) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	if err := m.VerifyExpectations(); err != nil {
+		t.Errorf("%s", err.Error())
+		return false
+	}
+	return true
+}
+
+func (m *mockery) resetExpectations() {
+	m.expectationLock.Lock()
+	defer m.expectationLock.Unlock()
+	for _, ex := range m.expectations {
+		ex.reset()
+	}
+}
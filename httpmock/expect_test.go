@@ -0,0 +1,169 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func expectationsConfig() {
+	Endpoint("/orders", func() {
+		Method("GET", func() {
+			Expect(AtLeast(1))
+			RespondWithString(200, "orders")
+		})
+		Method("POST", func() {
+			Expect(Times(2))
+			Respond(201)
+		})
+		Method("DELETE", func() {
+			Expect(Never())
+			Respond(204)
+		})
+	})
+}
+
+func TestExpectationsMet(t *testing.T) {
+	mock := Mockery(expectationsConfig)
+	serve(mock, "GET", "/orders")
+	serve(mock, "GET", "/orders?page=2")
+	serve(mock, "POST", "/orders")
+	serve(mock, "POST", "/orders")
+
+	assert.NoError(t, mock.VerifyExpectations())
+	assert.True(t, mock.AssertExpectations(&fakeT{}))
+}
+
+func TestExpectationsNotMet(t *testing.T) {
+	mock := Mockery(expectationsConfig)
+	serve(mock, "POST", "/orders?id=1")
+	serve(mock, "DELETE", "/orders?id=1")
+
+	err := mock.VerifyExpectations()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "3 expectation(s) not met:\n")
+		assert.Regexp(t, `\n  GET /orders \[defined at .*expect_test\.go:14.*\]: expected at least 1 call\(s\) but got 0\n`,
+			err.Error())
+		assert.Regexp(t, `\n  POST /orders \[defined at .*\]: expected exactly 2 call\(s\) but got 1\n    POST /orders\?id=1\n`,
+			err.Error())
+		assert.Regexp(t, `\n  DELETE /orders \[defined at .*\]: expected no calls but got 1\n    DELETE /orders\?id=1$`,
+			err.Error())
+	}
+
+	ft := &fakeT{}
+	assert.False(t, mock.AssertExpectations(ft))
+	assert.Equal(t, []string{err.Error()}, ft.errors)
+
+	mock.ResetJournal()
+	err = mock.VerifyExpectations()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "2 expectation(s) not met:\n")
+	}
+}
+
+func TestExpectationManyRequests(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				Expect(AtMost(2))
+				Respond(200)
+			})
+		})
+	})
+	for i := 0; i < 12; i++ {
+		code, _ := serve(mock, "GET", "/orders")
+		assert.Equal(t, http.StatusOK, code)
+	}
+	err := mock.VerifyExpectations()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "expected at most 2 call(s) but got 12\n")
+		assert.Contains(t, err.Error(), "\n    GET /orders\n    ... and 2 more")
+	}
+}
+
+func TestExpectConfigErrors(t *testing.T) {
+	_, err := MockeryE(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				Expect(Times(-1))
+				Respond(200)
+			})
+			Method("POST", func() {
+				Expect(AtMost(-1))
+				Respond(201)
+			})
+		})
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 2) {
+		for _, e := range err.(ConfigErrors) {
+			assert.Equal(t, "the number of calls for Expect must not be negative", e.Message)
+		}
+	}
+}
+
+func TestStartServerExpectations(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, expectationsConfig)
+
+	response, err := http.Post(server.URL+"/orders", "text/plain", nil)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+	}
+	ft.finish()
+	assert.Empty(t, ft.fatals)
+	if assert.Len(t, ft.errors, 1) {
+		assert.Contains(t, ft.errors[0], "2 expectation(s) not met:\n")
+		assert.Contains(t, ft.errors[0], "expected exactly 2 call(s) but got 1\n    POST /orders")
+	}
+}
+
+func TestExpectationsOfChangedEndpoints(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				Expect(Times(1))
+				RespondWithString(200, "orders")
+			})
+		})
+	})
+	ordersID := mock.Endpoints()[0].ID
+
+	_, err := mock.AddEndpoints(func(b *Builder) {
+		b.EndpointPattern("^/customers$", func() {
+			b.Expect(Times(1))
+			b.FixedDelay("soon")
+		})
+	})
+	assert.Error(t, err)
+	err = mock.SetEndpoint(ordersID, func(b *Builder) {
+		b.EndpointPattern("^/orders$", func() {
+			b.Expect(Times(1))
+			b.FixedDelay("later")
+		})
+	})
+	assert.Error(t, err)
+	assert.Contains(t, mock.VerifyExpectations().Error(), "1 expectation(s) not met:\n",
+		"the expectations of a failed configuration must not be registered")
+
+	ids, err := mock.AddEndpoints(func(b *Builder) {
+		b.EndpointPattern("^/customers$", func() {
+			b.Expect(Times(1))
+			b.Respond(200)
+		})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.RemoveEndpoint(ordersID))
+	_, err = mock.ReplaceEndpoints(ids, func(b *Builder) {
+		b.EndpointPattern("^/customers$", func() {
+			b.Expect(Never())
+			b.Respond(200)
+		})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.VerifyExpectations(), "the expectations of removed and replaced endpoints must be dropped")
+
+	mock.Reset()
+	assert.Contains(t, mock.VerifyExpectations().Error(), "GET /orders [defined at ")
+}
@@ -0,0 +1,94 @@
+package httpmock
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+)
+
+// FaultType is a way for a response to fail, see Fault.  The values are the names wiremock uses for the faults.
+type FaultType string
+
+const (
+	// ConnectionResetByPeer closes the connection so that the client sees it reset, e.g. "connection reset by peer".
+	ConnectionResetByPeer FaultType = "CONNECTION_RESET_BY_PEER"
+	// EmptyResponse closes the connection without writing anything.
+	EmptyResponse FaultType = "EMPTY_RESPONSE"
+	// MalformedResponseChunk writes a 200 status and headers for a chunked body followed by a chunk that can't be
+	// parsed, then closes the connection.
+	MalformedResponseChunk FaultType = "MALFORMED_RESPONSE_CHUNK"
+	// RandomDataThenClose writes garbage instead of a response and closes the connection.
+	RandomDataThenClose FaultType = "RANDOM_DATA_THEN_CLOSE"
+)
+
+var faults = map[FaultType]func(net.Conn){
+	ConnectionResetByPeer:  resetConnection,
+	EmptyResponse:          func(net.Conn) {},
+	MalformedResponseChunk: writeMalformedChunk,
+	RandomDataThenClose:    writeRandomData,
+}
+
+// Fault makes the response fail in the way given instead of responding, so that the resilience of clients can be
+// tested, e.g.
+//
+//    Endpoint("/orders", func() {
+//      Method("GET", func() {
+//        FixedDelay("50ms")
+//        Fault(ConnectionResetByPeer)
+//      })
+//    })
+//
+// The fault hijacks the connection, so it requires an HTTP/1.x server such as httptest.NewServer.  If the connection
+// can't be hijacked, e.g. when the mock is served by an httptest.ResponseRecorder, a 500 is written instead.  Requests
+// that fault are recorded in the journal with a status of 0.
+func Fault(fault FaultType) {
+	CurrentBuilder().Fault(fault)
+}
+
+// Fault makes the response fail in the way given instead of responding.
+func (b *Builder) Fault(fault FaultType) {
+	inject, ok := faults[fault]
+	if !ok {
+		b.Errorf("unknown fault %q", fault)
+		return
+	}
+	b.DecorateHandlerAfter(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			log.Printf("ERROR while injecting fault %s: the connection can't be hijacked", fault)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		conn, _, err := hijacker.Hijack()
+		if err != nil {
+			log.Printf("ERROR while injecting fault %s: %+v", fault, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		inject(conn)
+	}))
+}
+
+// resetConnection makes closing the connection send a TCP reset rather than a graceful close.
+func resetConnection(conn net.Conn) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+}
+
+func writeMalformedChunk(conn net.Conn) {
+	io.WriteString(conn, "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nlorem ipsum dolor sit amet\r\n")
+}
+
+func writeRandomData(conn net.Conn) {
+	data := make([]byte, 64)
+	rand.Read(data)
+	conn.Write(data)
+}
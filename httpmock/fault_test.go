@@ -0,0 +1,72 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFault(t *testing.T) {
+	mock := Mockery(func() {
+		for _, fault := range []FaultType{ConnectionResetByPeer, EmptyResponse, MalformedResponseChunk,
+			RandomDataThenClose} {
+			f := fault
+			Endpoint("/"+strings.ToLower(string(f)), func() {
+				Method("GET", func() {
+					Fault(f)
+				})
+			})
+		}
+	})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	for _, path := range []string{"/connection_reset_by_peer", "/empty_response", "/random_data_then_close"} {
+		response, err := http.Get(server.URL + path)
+		if !assert.Error(t, err, path) {
+			response.Body.Close()
+		}
+	}
+
+	response, err := http.Get(server.URL + "/malformed_response_chunk")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 200, response.StatusCode)
+		_, err = ioutil.ReadAll(response.Body)
+		assert.Error(t, err)
+		response.Body.Close()
+	}
+
+	requests := mock.Requests()
+	if assert.Len(t, requests, 4) {
+		for _, rr := range requests {
+			assert.Equal(t, 0, rr.Status)
+		}
+	}
+}
+
+func TestFaultWithoutHijacker(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/reset", func() {
+			b.Method("GET", func() {
+				b.Fault(ConnectionResetByPeer)
+			})
+		})
+	})
+	status, _ := serve(mock, "GET", "/reset")
+	assert.Equal(t, 500, status)
+	assert.Equal(t, 500, mock.Requests()[0].Status)
+}
+
+func TestUnknownFault(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.Fault("CONNECTION_LOST")
+	})
+	if assert.IsType(t, ConfigErrors{}, err) {
+		assert.Equal(t, `unknown fault "CONNECTION_LOST"`, err.(ConfigErrors)[0].Message)
+	}
+}
@@ -0,0 +1,19 @@
+// Package uuid generates the random UUIDs used to identify endpoints and recorded mappings.
+package uuid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// New returns a random, version 4, UUID, e.g. "0f8fad5b-d9cb-469f-a165-70867728950e".
+func New() string {
+	var u [16]byte
+	if _, err := io.ReadFull(rand.Reader, u[:]); err != nil {
+		panic(err.Error())
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
@@ -0,0 +1,196 @@
+package httpmock
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+)
+
+// DefaultJournalCapacity is the number of requests a mockery remembers unless JournalCapacity is used.
+const DefaultJournalCapacity = 1000
+
+// RecordedRequest is an entry in the request journal.  It captures the request as it was received along with the
+// endpoint that handled it and the response status and latency.  The endpoint is empty if no endpoint matched the
+// request.  The status is 0 if the connection was hijacked, e.g. by a Fault.  The violations are those found by
+// ValidateRequestAgainst.
+type RecordedRequest struct {
+	Timestamp  time.Time
+	Method     string
+	URL        *url.URL
+	Header     http.Header
+	Body       []byte
+	Endpoint   string
+	Status     int
+	Latency    time.Duration
+	Violations []RequestViolation
+}
+
+// Request rebuilds an *http.Request from the recorded data.  A new request with a fresh body is returned on every
+// call so that predicates that consume the body may be evaluated more than once.
+func (rr *RecordedRequest) Request() *http.Request {
+	return &http.Request{
+		Method:        rr.Method,
+		URL:           rr.URL,
+		RequestURI:    rr.URL.RequestURI(),
+		Host:          rr.URL.Host,
+		Header:        rr.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(rr.Body)),
+		ContentLength: int64(len(rr.Body)),
+	}
+}
+
+// journal is a bounded, in-memory record of the requests served by a mockery.  When the capacity is reached the
+// oldest entries are discarded.
+type journal struct {
+	lock     sync.Mutex
+	entries  []*RecordedRequest
+	next     int
+	full     bool
+	capacity int
+}
+
+func newJournal(capacity int) *journal {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &journal{entries: make([]*RecordedRequest, 0, minInt(capacity, 64)), capacity: capacity}
+}
+
+// enabled returns whether the journal keeps any requests.
+func (j *journal) enabled() bool {
+	return j.capacity > 0
+}
+
+func (j *journal) record(rr *RecordedRequest) {
+	if !j.enabled() {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if !j.full {
+		j.entries = append(j.entries, rr)
+		j.full = len(j.entries) == j.capacity
+		return
+	}
+	j.entries[j.next] = rr
+	j.next = (j.next + 1) % j.capacity
+}
+
+// requests returns the journal entries from oldest to newest.
+func (j *journal) requests() []*RecordedRequest {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	result := make([]*RecordedRequest, 0, len(j.entries))
+	result = append(result, j.entries[j.next:]...)
+	return append(result, j.entries[:j.next]...)
+}
+
+func (j *journal) reset() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.entries = j.entries[:0]
+	j.next = 0
+	j.full = false
+}
+
+func (j *journal) find(p predicate.Predicate) []*RecordedRequest {
+	found := make([]*RecordedRequest, 0, 10)
+	for _, rr := range j.requests() {
+		if p.Accept(rr.Request()) {
+			found = append(found, rr)
+		}
+	}
+	return found
+}
+
+func (j *journal) verify(p predicate.Predicate, times int) error {
+	found := j.find(p)
+	if len(found) != times {
+		return fmt.Errorf("expected %d matching request(s) in the journal but found %d", times, len(found))
+	}
+	return nil
+}
+
+// recordingResponseWriter keeps track of the status code written, the name set by Name, the delay injected and the
+// violations found by ValidateRequestAgainst so that they can be recorded in the journal and the metrics.  It also
+// delivers the body through the pacer, if one was set by ThrottleBandwidth or ChunkedDribbleDelay.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status     int
+	hijacked   bool
+	pacer      bodyPacer
+	name       string
+	delay      time.Duration
+	violations []RequestViolation
+	unmatched  bool
+}
+
+// Flush sends the data written so far to the client, if the underlying ResponseWriter supports it.
+func (w *recordingResponseWriter) Flush() {
+	flush(w.ResponseWriter)
+}
+
+// finish delivers what the pacer has held back, it is called once the handler has returned.
+func (w *recordingResponseWriter) finish() {
+	if w.pacer != nil && !w.hijacked {
+		w.pacer.finish(w.ResponseWriter)
+	}
+}
+
+// Hijack lets a Fault take over the connection, if the underlying ResponseWriter supports it.
+func (w *recordingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the ResponseWriter does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.pacer != nil {
+		return w.pacer.write(w.ResponseWriter, b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// JournalCapacity sets the number of requests the mockery will remember.  A capacity of zero or less disables the
+// journal.  It should be called at the top level of the Mockery config function.
+func JournalCapacity(capacity int) {
+	CurrentBuilder().JournalCapacity(capacity)
+}
+
+// JournalCapacity sets the number of requests the mockery will remember.  A capacity of zero or less disables the
+// journal.
+func (b *Builder) JournalCapacity(capacity int) {
+	b.mockery.journal = newJournal(capacity)
+}
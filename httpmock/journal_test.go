@@ -0,0 +1,135 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/go-http-matchers/predicate"
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestJournalRecordsRequests(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/foo/bar", func() {
+			Method("POST", func() {
+				Respond(201)
+			})
+		})
+		EndpointPattern("/snafu/.*", func() {
+			RespondWithString(200, "snafu")
+		})
+	})
+
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/foo/bar", strings.NewReader("<foo>bar</foo>")))
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/snafu/1?q=x", nil))
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	requests := mock.Requests()
+	if assert.Len(t, requests, 3) {
+		assert.Equal(t, "POST", requests[0].Method)
+		assert.Equal(t, "/foo/bar", requests[0].Endpoint)
+		assert.Equal(t, 201, requests[0].Status)
+		assert.Equal(t, "<foo>bar</foo>", string(requests[0].Body))
+
+		assert.Equal(t, "/snafu/.*", requests[1].Endpoint)
+		assert.Equal(t, 200, requests[1].Status)
+		assert.Equal(t, "x", requests[1].URL.Query().Get("q"))
+
+		assert.Equal(t, "", requests[2].Endpoint)
+		assert.Equal(t, 404, requests[2].Status)
+	}
+}
+
+func TestJournalVerify(t *testing.T) {
+	mock := Mockery(func() {
+		EndpointForCondition(True(), func() {
+			Respond(200)
+		})
+	})
+
+	for i := 0; i < 3; i++ {
+		mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil))
+	}
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/foo", strings.NewReader("<a><b>c</b></a>")))
+
+	assert.NoError(t, mock.Verify(MethodIs("GET"), 3))
+	assert.NoError(t, mock.Verify(PathEquals("/foo"), 4))
+	assert.NoError(t, mock.Verify(MethodIs("DELETE"), 0))
+	assert.Error(t, mock.Verify(MethodIs("GET"), 2))
+
+	// body predicates may be evaluated repeatedly against the journal.
+	assert.NoError(t, mock.Verify(BodyXPathEquals("/a/b", "c"), 1))
+	assert.NoError(t, mock.Verify(BodyXPathMatches("/a/b", regexp.MustCompile("^c$")), 1))
+
+	found := mock.FindRequests(MethodIs("PUT"))
+	if assert.Len(t, found, 1) {
+		body, err := ioutil.ReadAll(found[0].Request().Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "<a><b>c</b></a>", string(body))
+		assert.Contains(t, found[0].Endpoint, "journal_test.go")
+	}
+
+	mock.ResetJournal()
+	assert.Empty(t, mock.Requests())
+	assert.NoError(t, mock.Verify(True(), 0))
+}
+
+func TestJournalCapacity(t *testing.T) {
+	mock := Mockery(func() {
+		JournalCapacity(2)
+		EndpointForCondition(True(), func() {
+			Respond(200)
+		})
+	})
+
+	for _, path := range []string{"/1", "/2", "/3"} {
+		mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	requests := mock.Requests()
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, "/2", requests[0].URL.Path)
+		assert.Equal(t, "/3", requests[1].URL.Path)
+	}
+}
+
+func TestJournalDisabled(t *testing.T) {
+	mock := Mockery(func() {
+		JournalCapacity(0)
+		EndpointForCondition(True(), func() {
+			Respond(200)
+		})
+	})
+
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest("GET", "/foo", nil))
+	assert.Equal(t, 200, mockWriter.Code)
+	assert.Empty(t, mock.Requests())
+}
+
+func TestJournalDisabledLeavesBody(t *testing.T) {
+	var received io.ReadCloser
+	mock := Mockery(func() {
+		JournalCapacity(0)
+		EndpointForCondition(True(), func() {
+			DecorateHandlerBefore(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+				received = request.Body
+			}))
+			Respond(204)
+		})
+	})
+
+	body := ioutil.NopCloser(strings.NewReader("data"))
+	request := httptest.NewRequest("POST", "/upload", nil)
+	request.Body = body
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, request)
+	assert.Equal(t, 204, mockWriter.Code)
+	assert.True(t, received == body, "the body must not be buffered when the journal is disabled")
+}
@@ -0,0 +1,123 @@
+package httpmock
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// RateLimit limits the requests that reach the response to rps requests per second on average, allowing bursts of up
+// to burst requests.  Requests beyond the limit get the response defined by the limited config function instead, or
+// a 429 with a Retry-After header when it is nil.  RateLimit decorates the handler defined so far, so it must be used
+// after the response has been specified, e.g.
+//
+//    Method("GET", func() {
+//      RespondWithFile(200, "./orders.json")
+//      RateLimit(10, 5, func() {
+//        Header("Retry-After", "1")
+//        RespondWithString(429, "slow down")
+//      })
+//    })
+//
+func RateLimit(rps float64, burst int, limited func()) {
+	CurrentBuilder().RateLimit(rps, burst, limited)
+}
+
+// RateLimit limits the requests that reach the response to rps requests per second on average, allowing bursts of up
+// to burst requests.
+func (b *Builder) RateLimit(rps float64, burst int, limited func()) {
+	if rps <= 0 {
+		b.Errorf("rps for RateLimit must be greater than 0 but is %v", rps)
+		return
+	}
+	if burst <= 0 {
+		b.Errorf("burst for RateLimit must be greater than 0 but is %d", burst)
+		return
+	}
+	tb := &tokenBucket{rate: rps, burst: float64(burst), tokens: float64(burst)}
+	limitedHandler := b.limitedHandler(limited)
+	delegate := b.handler
+	b.handler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		retryAfter, ok := tb.take()
+		if ok {
+			delegate.ServeHTTP(w, request)
+			return
+		}
+		if limitedHandler == nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		limitedHandler.ServeHTTP(w, request)
+	})
+}
+
+// MaxConcurrent limits the number of requests that are handled at once to n.  Requests beyond the limit get the
+// response defined by the limited config function instead, or a 503 when it is nil.  Like RateLimit it must be used
+// after the response has been specified.
+func MaxConcurrent(n int, limited func()) {
+	CurrentBuilder().MaxConcurrent(n, limited)
+}
+
+// MaxConcurrent limits the number of requests that are handled at once to n.
+func (b *Builder) MaxConcurrent(n int, limited func()) {
+	if n <= 0 {
+		b.Errorf("n for MaxConcurrent must be greater than 0 but is %d", n)
+		return
+	}
+	slots := make(chan struct{}, n)
+	limitedHandler := b.limitedHandler(limited)
+	delegate := b.handler
+	b.handler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			delegate.ServeHTTP(w, request)
+		default:
+			if limitedHandler == nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			limitedHandler.ServeHTTP(w, request)
+		}
+	})
+}
+
+// limitedHandler builds the response for requests that are turned away by a limit, it is nil if limited is nil.
+func (b *Builder) limitedHandler(limited func()) http.Handler {
+	if limited == nil {
+		return nil
+	}
+	outerHandler := b.handler
+	b.handler = NoopHandler
+	limited()
+	handler := b.handler
+	b.handler = outerHandler
+	return handler
+}
+
+// take takes a token from the bucket if there is one, otherwise it returns how long it will be until there is.
+func (tb *tokenBucket) take() (time.Duration, bool) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	now := time.Now()
+	if !tb.last.IsZero() {
+		tb.tokens = math.Min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	}
+	tb.last = now
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second)), false
+}
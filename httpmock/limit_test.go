@@ -0,0 +1,90 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				RespondWithString(200, "orders")
+				RateLimit(1, 2, nil)
+			})
+		})
+		Endpoint("/customers", func() {
+			Method("GET", func() {
+				RespondWithString(200, "customers")
+				RateLimit(1, 1, func() {
+					RespondWithString(503, "busy")
+				})
+			})
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		code, body := serve(mock, "GET", "/orders")
+		assert.Equal(t, 200, code)
+		assert.Equal(t, "orders", body)
+	}
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, httptest.NewRequest("GET", "/orders", nil))
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Empty(t, w.Body.String())
+
+	code, body := serve(mock, "GET", "/customers")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "customers", body)
+	code, body = serve(mock, "GET", "/customers")
+	assert.Equal(t, 503, code)
+	assert.Equal(t, "busy", body)
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/slow", func() {
+			b.Method("GET", func() {
+				b.FixedDelay("200ms")
+				b.RespondWithString(200, "done")
+				b.MaxConcurrent(2, nil)
+			})
+		})
+	})
+
+	codes := make([]int, 3)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i], _ = serve(mock, "GET", "/slow")
+		}(i)
+	}
+	wg.Wait()
+	sort.Ints(codes)
+	assert.Equal(t, []int{200, 200, 503}, codes)
+
+	code, _ := serve(mock, "GET", "/slow")
+	assert.Equal(t, 200, code)
+}
+
+func TestLimitConfigErrors(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.RateLimit(0, 1, nil)
+		b.RateLimit(1, 0, nil)
+		b.MaxConcurrent(0, nil)
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 3) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "rps for RateLimit must be greater than 0 but is 0", errs[0].Message)
+		assert.Equal(t, "burst for RateLimit must be greater than 0 but is 0", errs[1].Message)
+		assert.Equal(t, "n for MaxConcurrent must be greater than 0 but is 0", errs[2].Message)
+	}
+}
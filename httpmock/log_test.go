@@ -12,7 +12,7 @@ import (
 
 func TestLogRequest(t *testing.T) {
 	var counter countingHandler
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	LogRequest()
 	RespondWithString(200, "The quick brown fox jumped over the lazy dogs.")
 	testURL, _ := url.ParseRequestURI("http://localhost/foo")
@@ -24,7 +24,7 @@ func TestLogRequest(t *testing.T) {
 	mockWriter := httptest.NewRecorder()
 	var str bytes.Buffer
 	log.SetOutput(&str)
-	currentMockHandler.ServeHTTP(mockWriter, request)
+	CurrentHandler().ServeHTTP(mockWriter, request)
 	logMessage := str.String()
 	assert.Equal(t, "Request:\nGET /foo HTTP/0.0\r\nHost: localhost\r\n\r\n",logMessage[20:])
 }
@@ -2,9 +2,24 @@ package httpmock
 
 import (
 	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"strings"
 )
 
 // Method is a DSL element that is used within an Endpoint element to define a method handler.
 func Method(method string, configFunc func()) {
-	Case(predicate.StringEquals(method), configFunc)
+	CurrentBuilder().Method(method, configFunc)
+}
+
+// Method is a DSL element that is used within an Endpoint element to define a method handler.
+func (b *Builder) Method(method string, configFunc func()) {
+	outerScope := b.scope
+	b.scope = strings.TrimSpace(method + " " + outerScope)
+	outerMethod := b.method
+	defer func() { b.scope, b.method = outerScope, outerMethod }()
+	// The method is checked when the endpoint is selected, so its transitions can be made then too.
+	transitions := b.transitions
+	b.addCase("Method", "an Endpoint", Describe("method is "+method, predicate.StringEquals(method)), func() {
+		b.transitions, b.method = transitions, method
+		configFunc()
+	})
 }
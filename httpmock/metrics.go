@@ -0,0 +1,233 @@
+package httpmock
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsBuckets are the upper bounds, in seconds, of the buckets of the histograms served by Metrics.
+var MetricsBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	endpoint, method string
+	status           int
+}
+
+// histogram counts the durations observed in each bucket, they are updated atomically.  The count and the sum, in
+// nanoseconds, come first so that they are aligned for atomic operations on 32 bit platforms.
+type histogram struct {
+	count  int64
+	sum    int64
+	counts []int64
+}
+
+// metrics holds the counters and histograms of the requests served by a mockery, see Metrics.  The counters are
+// updated atomically, the lock guards the maps and is only held exclusively to add the counters of a new endpoint,
+// method or status.
+type metrics struct {
+	lock      sync.RWMutex
+	buckets   []float64
+	requests  map[requestKey]*int64
+	serveTime map[string]*histogram
+	delay     map[string]*histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		buckets:   append([]float64(nil), MetricsBuckets...),
+		requests:  make(map[requestKey]*int64),
+		serveTime: make(map[string]*histogram),
+		delay:     make(map[string]*histogram),
+	}
+}
+
+// Metrics defines an endpoint at the url given that serves metrics about the requests served by the mock in the
+// Prometheus text format, so that a performance test can confirm that the mock is not the bottleneck.  The metrics
+// are:
+//
+//    mockery_requests_total{endpoint,method,status}   the number of requests served
+//    mockery_serve_seconds{endpoint}                  a histogram of the time taken to serve the requests
+//    mockery_delay_seconds{endpoint}                  a histogram of the delay injected by the delay elements
+//
+// The endpoint label is the url or pattern of the endpoint, or the name given to it with Name, it is empty for
+// requests that no endpoint matched.  It should be called at the top level of the Mockery config function, e.g.
+//
+//    Mockery(func() {
+//      Metrics("/metrics")
+//      Endpoint("/orders", func() {
+//        ...
+//      })
+//    })
+//
+func Metrics(url string) {
+	CurrentBuilder().metricsEndpoint(url, callerLocation(1))
+}
+
+// Metrics defines an endpoint at the url given that serves metrics about the requests served by the mock in the
+// Prometheus text format.
+func (b *Builder) Metrics(url string) {
+	b.metricsEndpoint(url, callerLocation(1))
+}
+
+func (b *Builder) metricsEndpoint(url, location string) {
+	if b.mockery.metrics == nil {
+		b.mockery.metrics = newMetrics()
+	}
+	mm := b.mockery.metrics
+	b.endpoint(url, location, func() {
+		b.Method("GET", func() {
+			b.Header("Content-Type", "text/plain; version=0.0.4")
+			b.DecorateHandlerAfter(mm)
+		})
+	})
+}
+
+// Name names the endpoint in the request journal and in the Metrics, instead of its url or pattern.  It applies to
+// the responses defined after it, so it is usually the first element of an Endpoint, e.g.
+//
+//    Endpoint("/orders/", func() {
+//      Name("orders")
+//      Method("GET", func() {
+//        ...
+//      })
+//    })
+//
+func Name(name string) {
+	CurrentBuilder().Name(name)
+}
+
+// Name names the endpoint in the request journal and in the Metrics, instead of its url or pattern.
+func (b *Builder) Name(name string) {
+	if name == "" {
+		b.Errorf("name must not be empty")
+		return
+	}
+	b.DecorateHandlerBefore(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if rw, ok := w.(*recordingResponseWriter); ok {
+			rw.name = name
+		}
+	}))
+}
+
+// addDelay counts the delay injected by a delay element towards the delay recorded for the request.
+func addDelay(w http.ResponseWriter, d time.Duration) {
+	if rw, ok := w.(*recordingResponseWriter); ok {
+		rw.delay += d
+	}
+}
+
+func (mm *metrics) record(endpoint, method string, status int, serveTime, delay time.Duration) {
+	key := requestKey{endpoint, method, status}
+	mm.lock.RLock()
+	requests, serveTimes, delays := mm.requests[key], mm.serveTime[endpoint], mm.delay[endpoint]
+	mm.lock.RUnlock()
+	if requests == nil || serveTimes == nil || delays == nil {
+		requests, serveTimes, delays = mm.add(key)
+	}
+	atomic.AddInt64(requests, 1)
+	serveTimes.observe(mm.buckets, serveTime)
+	delays.observe(mm.buckets, delay)
+}
+
+// add returns the counter for the key and the histograms for its endpoint, adding those that another request has
+// not added already.
+func (mm *metrics) add(key requestKey) (*int64, *histogram, *histogram) {
+	mm.lock.Lock()
+	defer mm.lock.Unlock()
+	requests, ok := mm.requests[key]
+	if !ok {
+		requests = new(int64)
+		mm.requests[key] = requests
+	}
+	return requests, mm.histogram(mm.serveTime, key.endpoint), mm.histogram(mm.delay, key.endpoint)
+}
+
+func (mm *metrics) histogram(histograms map[string]*histogram, endpoint string) *histogram {
+	h, ok := histograms[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(mm.buckets))}
+		histograms[endpoint] = h
+	}
+	return h
+}
+
+// observe counts the duration, the count is incremented before the buckets so that a reader that loads the buckets
+// before the count never sees a bucket with more observations than the count.
+func (h *histogram) observe(buckets []float64, d time.Duration) {
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(d))
+	seconds := d.Seconds()
+	for i, le := range buckets {
+		if seconds <= le {
+			atomic.AddInt64(&h.counts[i], 1)
+		}
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (mm *metrics) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	mm.lock.RLock()
+	defer mm.lock.RUnlock()
+
+	keys := make([]requestKey, 0, len(mm.requests))
+	for k := range mm.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+	fmt.Fprintln(w, "# HELP mockery_requests_total The number of requests served by the mock.")
+	fmt.Fprintln(w, "# TYPE mockery_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "mockery_requests_total{endpoint=\"%s\",method=\"%s\",status=\"%d\"} %d\n",
+			escapeLabel(k.endpoint), escapeLabel(k.method), k.status, atomic.LoadInt64(mm.requests[k]))
+	}
+	mm.writeHistograms(w, "mockery_serve_seconds", "The time taken to serve the requests.", mm.serveTime)
+	mm.writeHistograms(w, "mockery_delay_seconds", "The delay injected by the delay elements.", mm.delay)
+}
+
+func (mm *metrics) writeHistograms(w io.Writer, name, help string, histograms map[string]*histogram) {
+	endpoints := make([]string, 0, len(histograms))
+	for e := range histograms {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for _, e := range endpoints {
+		h := histograms[e]
+		label := escapeLabel(e)
+		counts := make([]int64, len(h.counts))
+		for i := range counts {
+			counts[i] = atomic.LoadInt64(&h.counts[i])
+		}
+		count := atomic.LoadInt64(&h.count)
+		sum := time.Duration(atomic.LoadInt64(&h.sum)).Seconds()
+		for i, le := range mm.buckets {
+			fmt.Fprintf(w, "%s_bucket{endpoint=\"%s\",le=\"%s\"} %d\n", name, label,
+				strconv.FormatFloat(le, 'g', -1, 64), counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{endpoint=\"%s\",le=\"+Inf\"} %d\n", name, label, count)
+		fmt.Fprintf(w, "%s_sum{endpoint=\"%s\"} %s\n", name, label, strconv.FormatFloat(sum, 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{endpoint=\"%s\"} %d\n", name, label, count)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,69 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	mock := Mockery(func() {
+		Metrics("/metrics")
+		Endpoint("/orders", func() {
+			Name("orders")
+			Method("GET", func() {
+				FixedDelay("20ms")
+				RespondWithString(200, "orders")
+			})
+		})
+		Endpoint("/customers", func() {
+			Method("GET", func() {
+				RespondWithString(200, "customers")
+			})
+			Method("POST", func() {
+				Respond(201)
+			})
+		})
+	})
+
+	serve(mock, "GET", "/orders")
+	serve(mock, "GET", "/orders")
+	serve(mock, "GET", "/customers")
+	serve(mock, "POST", "/customers")
+	serve(mock, "GET", "/unknown")
+
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4", w.Header().Get("Content-Type"))
+	metrics := w.Body.String()
+	assert.Contains(t, metrics, "# TYPE mockery_requests_total counter\n")
+	assert.Contains(t, metrics, `mockery_requests_total{endpoint="",method="GET",status="404"} 1`+"\n")
+	assert.Contains(t, metrics, `mockery_requests_total{endpoint="/customers",method="GET",status="200"} 1`+"\n")
+	assert.Contains(t, metrics, `mockery_requests_total{endpoint="/customers",method="POST",status="201"} 1`+"\n")
+	assert.Contains(t, metrics, `mockery_requests_total{endpoint="orders",method="GET",status="200"} 2`+"\n")
+	assert.Contains(t, metrics, "# TYPE mockery_serve_seconds histogram\n")
+	assert.Contains(t, metrics, `mockery_serve_seconds_count{endpoint="orders"} 2`+"\n")
+	assert.Contains(t, metrics, `mockery_delay_seconds_bucket{endpoint="orders",le="0.01"} 0`+"\n")
+	assert.Contains(t, metrics, `mockery_delay_seconds_bucket{endpoint="orders",le="0.05"} 2`+"\n")
+	assert.Contains(t, metrics, `mockery_delay_seconds_bucket{endpoint="orders",le="+Inf"} 2`+"\n")
+	assert.Contains(t, metrics, `mockery_delay_seconds_bucket{endpoint="/customers",le="0.001"} 2`+"\n")
+
+	requests := mock.Requests()
+	if assert.Len(t, requests, 6) {
+		assert.Equal(t, "orders", requests[0].Endpoint)
+		assert.Equal(t, "/customers", requests[2].Endpoint)
+		assert.Equal(t, "/metrics", requests[5].Endpoint)
+	}
+}
+
+func TestEmptyName(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.Name("")
+	})
+	if assert.IsType(t, ConfigErrors{}, err) {
+		assert.Equal(t, "name must not be empty", err.(ConfigErrors)[0].Message)
+	}
+}
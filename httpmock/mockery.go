@@ -1,73 +1,538 @@
 package httpmock
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"io/ioutil"
 	"net/http"
 	"sort"
+	"sync"
+	"time"
 )
 
+// EndpointInfo describes an endpoint of a mockery.
+type EndpointInfo struct {
+	// ID identifies the endpoint, it may be used to remove or replace the endpoint.
+	ID string
+	// Name is the url or pattern of the endpoint, or its location if it is selected by a predicate.
+	Name string
+	// Priority is the priority of the endpoint, endpoints with lower numbers are considered first.
+	Priority int
+	// Location is the place the endpoint was defined, usually the file and line number of the DSL element.
+	Location string
+}
+
 type mockeryHandler struct {
-	priority  int
-	predicate predicate.Predicate
-	handler   http.Handler
+	EndpointInfo
+	predicate   predicate.Predicate
+	handler     http.Handler
+	transitions []scenarioTransition
 }
 
 type byPriority []*mockeryHandler
 
 func (a byPriority) Len() int           { return len(a) }
 func (a byPriority) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byPriority) Less(i, j int) bool { return a[i].priority < a[j].priority }
+func (a byPriority) Less(i, j int) bool { return a[i].Priority < a[j].Priority }
+
+// Mock is the http.Handler produced by Mockery.  Besides serving the mock endpoints, it keeps a journal of the
+// requests it has served so that tests can verify the traffic sent to it.  The endpoints of a Mock may be changed
+// while it is serving requests.
+type Mock interface {
+	http.Handler
+
+	// Requests returns every request in the journal, oldest first.
+	Requests() []*RecordedRequest
+
+	// FindRequests returns the requests in the journal that are accepted by the predicate.  The predicate is passed
+	// an *http.Request rebuilt from the journal entry, so the predicates in go-http-matchers may be used.
+	FindRequests(predicate predicate.Predicate) []*RecordedRequest
+
+	// UnmatchedRequests returns the requests in the journal that no endpoint matched, oldest first.
+	UnmatchedRequests() []*RecordedRequest
+
+	// InvalidRequests returns the requests in the journal that ValidateRequestAgainst found violations in, oldest
+	// first.
+	InvalidRequests() []*RecordedRequest
+
+	// Verify returns an error unless exactly 'times' requests in the journal are accepted by the predicate.
+	Verify(predicate predicate.Predicate, times int) error
+
+	// VerifyExpectations returns an error describing every expectation declared with Expect that has not been met,
+	// along with the requests it got.
+	VerifyExpectations() error
+
+	// AssertExpectations fails the test if any expectation declared with Expect has not been met, see
+	// VerifyExpectations.  It returns whether the expectations were met.
+	AssertExpectations(t TestingT) bool
+
+	// ResetJournal discards every request in the journal, the calls counted by Expect and the unmatched requests
+	// counted for StartServer.
+	ResetJournal()
+
+	// ResetScenarios returns every scenario to the ScenarioStarted state and every Sequence or RoundRobin to its first
+	// Step.
+	ResetScenarios()
+
+	// Endpoints lists the endpoints in the order they are considered.
+	Endpoints() []EndpointInfo
+
+	// AddEndpoints defines more endpoints using the Builder passed to the configFunc and returns their ids.  If the
+	// configuration has errors no endpoint is added and the ConfigErrors are returned.
+	AddEndpoints(configFunc func(b *Builder)) ([]string, error)
+
+	// SetEndpoint defines the endpoint with the given id, replacing any endpoint that already has the id.  The
+	// configFunc must define exactly one endpoint.  If the configuration has errors the endpoints are unchanged and the
+	// ConfigErrors are returned.
+	SetEndpoint(id string, configFunc func(b *Builder)) error
+
+	// RemoveEndpoint removes the endpoint with the given id, and the expectations declared with Expect for it.
+	RemoveEndpoint(id string) error
+
+	// ReplaceEndpoints removes the endpoints with the given ids and defines the endpoints of the configFunc in their
+	// place, it returns the ids of the new endpoints.  Requests see either the old or the new endpoints, never a mix,
+	// and requests in flight complete with the endpoints they started with.  Ids with no endpoint are ignored.  If the
+	// configuration has errors the endpoints are unchanged and the ConfigErrors are returned.  The endpoints Reset
+	// restores are replaced too, so that a reload of the files endpoints were defined from is not undone by Reset.
+	ReplaceEndpoints(ids []string, configFunc func(b *Builder)) ([]string, error)
+
+	// Reset restores the endpoints the mockery was built with, as changed by ReplaceEndpoints, and their expectations,
+	// and resets the journal and the scenarios.
+	Reset()
+}
 
 type mockery struct {
-	mux      *http.ServeMux
-	handlers byPriority
+	// lock guards handlers, mux and muxEndpoints, they are never modified in place once built so that requests may be
+	// served from a snapshot.
+	lock            sync.RWMutex
+	handlers        byPriority
+	initialHandlers byPriority
+
+	// mux serves the endpoints defined for a url, muxEndpoints, through the handler muxHandler.  Removing one of them
+	// replaces the mux with one built from the others.
+	mux                 *http.ServeMux
+	muxHandler          *servingMux
+	muxEndpoints        []*muxEndpoint
+	initialMux          *http.ServeMux
+	initialMuxEndpoints []*muxEndpoint
+
+	journal      *journal
+	metrics      *metrics
+	diagnose     bool
+	scenarioLock sync.Mutex
+	scenarios    map[string]*scenario
+	sequences    []*sequenceSet
+
+	expectationLock     sync.Mutex
+	expectations        []*expectation
+	initialExpectations []*expectation
+
+	// unmatchedLock guards the count of the requests no endpoint, or no case of an endpoint's Switch, matched and the
+	// first of them.  They are kept apart from the journal so that they are counted even when it is disabled or full.
+	unmatchedLock    sync.Mutex
+	unmatchedCount   int
+	unmatchedSamples []string
 }
 
+// maxUnmatchedSamples is the number of unmatched requests a mockery remembers to report.
+const maxUnmatchedSamples = 10
+
 func (m *mockery) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	start := time.Now()
+	// The body is buffered, so that each endpoint's predicate can read it, only if the journal records it or the
+	// diagnostics may show it.  The body predicates of this package restore the body themselves.
+	var body []byte
+	buffered := request.Body != nil && (m.journal.enabled() || m.diagnose)
+	if buffered {
+		body, _ = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+	}
+	resetBody := func() {
+		if buffered {
+			request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+	}
+	m.lock.RLock()
+	handlers := m.handlers
+	m.lock.RUnlock()
+	rw := &recordingResponseWriter{ResponseWriter: w}
+	endpoint := ""
+	served := false
+	for _, h := range handlers {
+		resetBody()
+		if h.selects(request) {
+			endpoint = m.endpointName(h, request)
+			resetBody()
+			h.handler.ServeHTTP(rw, request)
+			rw.finish()
+			served = true
+			break
+		}
+	}
+	if !served {
+		if m.diagnose {
+			writeDiagnostic(rw, m.diagnoseUnmatched(handlers, request, resetBody))
+		} else {
+			rw.WriteHeader(404)
+		}
+	}
+	if !served || rw.unmatched {
+		m.countUnmatched(request)
+	}
+	if rw.status == 0 && !rw.hijacked {
+		rw.status = http.StatusOK
+	}
+	if rw.name != "" {
+		endpoint = rw.name
+	}
+	latency := time.Since(start)
+	if m.metrics != nil {
+		m.metrics.record(endpoint, request.Method, rw.status, latency, rw.delay)
+	}
+	m.journal.record(&RecordedRequest{
+		Timestamp:  start,
+		Method:     request.Method,
+		URL:        request.URL,
+		Header:     request.Header,
+		Body:       body,
+		Endpoint:   endpoint,
+		Status:     rw.status,
+		Latency:    latency,
+		Violations: rw.violations,
+	})
+}
+
+// countUnmatched counts a request that matched no endpoint, or no case of its endpoint.
+func (m *mockery) countUnmatched(request *http.Request) {
+	m.unmatchedLock.Lock()
+	defer m.unmatchedLock.Unlock()
+	m.unmatchedCount++
+	if len(m.unmatchedSamples) < maxUnmatchedSamples {
+		m.unmatchedSamples = append(m.unmatchedSamples, request.Method+" "+request.URL.RequestURI())
+	}
+}
+
+// unmatchedRequests returns the number of unmatched requests counted since the journal was last reset and the first
+// of them, e.g. "GET /orders?id=1".
+func (m *mockery) unmatchedRequests() (int, []string) {
+	m.unmatchedLock.Lock()
+	defer m.unmatchedLock.Unlock()
+	return m.unmatchedCount, append([]string(nil), m.unmatchedSamples...)
+}
+
+// endpointName returns the name recorded in the journal for the handler.  Endpoints registered with the ServeMux are
+// named by the pattern the mux selected.
+func (m *mockery) endpointName(h *mockeryHandler, request *http.Request) string {
+	if m.servesMux(h) {
+		_, pattern := m.currentMux().Handler(request)
+		return pattern
+	}
+	return h.Name
+}
+
+func (m *mockery) Requests() []*RecordedRequest {
+	return m.journal.requests()
+}
+
+func (m *mockery) FindRequests(predicate predicate.Predicate) []*RecordedRequest {
+	return m.journal.find(predicate)
+}
+
+func (m *mockery) UnmatchedRequests() []*RecordedRequest {
+	var unmatched []*RecordedRequest
+	for _, rr := range m.journal.requests() {
+		if rr.Endpoint == "" {
+			unmatched = append(unmatched, rr)
+		}
+	}
+	return unmatched
+}
+
+func (m *mockery) InvalidRequests() []*RecordedRequest {
+	var invalid []*RecordedRequest
+	for _, rr := range m.journal.requests() {
+		if len(rr.Violations) > 0 {
+			invalid = append(invalid, rr)
+		}
+	}
+	return invalid
+}
+
+func (m *mockery) Verify(predicate predicate.Predicate, times int) error {
+	return m.journal.verify(predicate, times)
+}
+
+func (m *mockery) ResetJournal() {
+	m.journal.reset()
+	m.resetExpectations()
+	m.unmatchedLock.Lock()
+	m.unmatchedCount = 0
+	m.unmatchedSamples = nil
+	m.unmatchedLock.Unlock()
+}
+
+func (m *mockery) Endpoints() []EndpointInfo {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	endpoints := make([]EndpointInfo, 0, len(m.handlers)+len(m.muxEndpoints))
 	for _, h := range m.handlers {
-		if h.predicate.Accept(request) {
-			h.handler.ServeHTTP(w, request)
-			return
+		if m.servesMux(h) {
+			for _, e := range m.muxEndpoints {
+				endpoints = append(endpoints, e.EndpointInfo)
+			}
+		} else {
+			endpoints = append(endpoints, h.EndpointInfo)
+		}
+	}
+	return endpoints
+}
+
+// runtimeBuilder returns a Builder that defines endpoints for the mockery while it is serving requests.  The
+// endpoints don't share the mockery's ServeMux so that they may be removed later.
+func (m *mockery) runtimeBuilder() *Builder {
+	return &Builder{mockery: m, handler: NoopHandler, isolated: true}
+}
+
+// configureRuntimeBuilder returns a runtime builder configured by the configFunc, or the configuration errors.
+func (m *mockery) configureRuntimeBuilder(configFunc func(b *Builder)) (*Builder, error) {
+	b := m.runtimeBuilder()
+	b.configure(func() { configFunc(b) })
+	return b, b.Err()
+}
+
+func (m *mockery) AddEndpoints(configFunc func(b *Builder)) ([]string, error) {
+	b, err := m.configureRuntimeBuilder(configFunc)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(b.handlers))
+	for i, h := range b.handlers {
+		ids[i] = h.ID
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.setHandlers(append(append(make(byPriority, 0, len(m.handlers)+len(b.handlers)), m.handlers...), b.handlers...))
+	m.addExpectations(b)
+	return ids, nil
+}
+
+func (m *mockery) SetEndpoint(id string, configFunc func(b *Builder)) error {
+	b, err := m.configureRuntimeBuilder(configFunc)
+	if err != nil {
+		return err
+	}
+	if len(b.handlers) != 1 {
+		return fmt.Errorf("exactly one endpoint must be defined for %s but %d were", id, len(b.handlers))
+	}
+	for _, ex := range b.expectations {
+		if ex.endpoint == b.handlers[0].ID {
+			ex.endpoint = id
+		}
+	}
+	b.handlers[0].ID = id
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.removeEndpoint(id)
+	m.setHandlers(append(append(make(byPriority, 0, len(m.handlers)+1), m.handlers...), b.handlers[0]))
+	m.addExpectations(b)
+	return nil
+}
+
+func (m *mockery) ReplaceEndpoints(ids []string, configFunc func(b *Builder)) ([]string, error) {
+	b, err := m.configureRuntimeBuilder(configFunc)
+	if err != nil {
+		return nil, err
+	}
+	newIDs := make([]string, len(b.handlers))
+	for i, h := range b.handlers {
+		newIDs[i] = h.ID
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, id := range ids {
+		m.removeEndpoint(id)
+	}
+	m.setHandlers(append(append(make(byPriority, 0, len(m.handlers)+len(b.handlers)), m.handlers...), b.handlers...))
+	m.addExpectations(b)
+	m.replaceInitialEndpoints(ids, b)
+	return newIDs, nil
+}
+
+// replaceInitialEndpoints replaces the endpoints with the given ids, among those Reset restores, with the endpoints of
+// the builder, the lock must be held.
+func (m *mockery) replaceInitialEndpoints(ids []string, b *Builder) {
+	replaced := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		replaced[id] = true
+	}
+	handlers := make(byPriority, 0, len(m.initialHandlers)+len(b.handlers))
+	for _, h := range m.initialHandlers {
+		if !replaced[h.ID] {
+			handlers = append(handlers, h)
 		}
 	}
-	w.WriteHeader(404)
+	handlers = append(handlers, b.handlers...)
+	sort.Stable(handlers)
+	m.initialHandlers = handlers
+	muxEndpoints := make([]*muxEndpoint, 0, len(m.initialMuxEndpoints))
+	for _, e := range m.initialMuxEndpoints {
+		if !replaced[e.ID] {
+			muxEndpoints = append(muxEndpoints, e)
+		}
+	}
+	if len(muxEndpoints) != len(m.initialMuxEndpoints) {
+		m.initialMux = newServeMux(muxEndpoints)
+		m.initialMuxEndpoints = muxEndpoints
+	}
+	m.expectationLock.Lock()
+	defer m.expectationLock.Unlock()
+	expectations := make([]*expectation, 0, len(m.initialExpectations)+len(b.expectations))
+	for _, ex := range m.initialExpectations {
+		if !replaced[ex.endpoint] {
+			expectations = append(expectations, ex)
+		}
+	}
+	m.initialExpectations = append(expectations, b.expectations...)
 }
 
-func (m *mockery) Handle(path string, handler http.Handler) {
-	if m.mux == nil {
-		m.mux = http.NewServeMux()
-		m.HandleForCondition(DefaultPriority, predicate.PredicateFunc(func(r interface{}) bool {
-			_, p := m.mux.Handler(r.(*http.Request))
-			return p != ""
-		}), m.mux)
+func (m *mockery) RemoveEndpoint(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.removeEndpoint(id) {
+		return fmt.Errorf("there is no endpoint with id %s", id)
 	}
-	m.mux.Handle(path, handler)
+	return nil
 }
 
-func (m *mockery) HandleForCondition(priority int, predicate predicate.Predicate, handler http.Handler) {
-	m.handlers = append(m.handlers, &mockeryHandler{priority, predicate, handler})
+// removeEndpoint removes the endpoint with the given id, and its expectations, and returns true if there was one, the
+// lock must be held.
+func (m *mockery) removeEndpoint(id string) bool {
+	m.dropExpectations(id)
+	for i, h := range m.handlers {
+		if h.ID == id {
+			m.setHandlers(append(append(make(byPriority, 0, len(m.handlers)-1), m.handlers[:i]...), m.handlers[i+1:]...))
+			return true
+		}
+	}
+	for i, e := range m.muxEndpoints {
+		if e.ID == id {
+			endpoints := append(append(make([]*muxEndpoint, 0, len(m.muxEndpoints)-1), m.muxEndpoints[:i]...),
+				m.muxEndpoints[i+1:]...)
+			m.mux = newServeMux(endpoints)
+			m.muxEndpoints = endpoints
+			return true
+		}
+	}
+	return false
 }
 
-var (
-	currentMockery     *mockery
-	currentMockHandler http.Handler
-)
+// setHandlers sorts the handlers and makes them current, the lock must be held.
+func (m *mockery) setHandlers(handlers byPriority) {
+	sort.Stable(handlers)
+	m.handlers = handlers
+}
+
+func (m *mockery) Reset() {
+	m.lock.Lock()
+	m.handlers = m.initialHandlers
+	m.mux = m.initialMux
+	m.muxEndpoints = m.initialMuxEndpoints
+	m.lock.Unlock()
+	m.expectationLock.Lock()
+	m.expectations = m.initialExpectations
+	m.expectationLock.Unlock()
+	m.ResetJournal()
+	m.ResetScenarios()
+}
+
+// muxEndpoint is an endpoint served by the mockery's ServeMux.
+type muxEndpoint struct {
+	EndpointInfo
+	handler http.Handler
+}
+
+// newServeMux returns a ServeMux that serves the endpoints.
+func newServeMux(endpoints []*muxEndpoint) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, e := range endpoints {
+		mux.Handle(e.Name, e.handler)
+	}
+	return mux
+}
+
+// servingMux serves requests with the mockery's current ServeMux.
+type servingMux struct {
+	m *mockery
+}
+
+func (s *servingMux) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	s.m.currentMux().ServeHTTP(w, request)
+}
+
+// currentMux returns the ServeMux that serves the endpoints defined for a url.
+func (m *mockery) currentMux() *http.ServeMux {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.mux
+}
+
+// servesMux returns true if the handler is the one that serves the endpoints of the ServeMux.
+func (m *mockery) servesMux(h *mockeryHandler) bool {
+	return m.muxHandler != nil && h.handler == http.Handler(m.muxHandler)
+}
+
+// muxAccepts is the predicate for the ServeMux, it accepts requests the mux has a pattern for.
+func (m *mockery) muxAccepts(r interface{}) bool {
+	_, pattern := m.currentMux().Handler(r.(*http.Request))
+	return pattern != ""
+}
 
 // Mockery contains the top level dispatcher.  This method establishes the root handler and the configFunc is called to
 // create handlers for the various mocks.  Once the config method returns some clean up actions will occur and the
-// mock handler will be returned.
-func Mockery(configFunc func()) http.Handler {
-	currentMockery = &mockery{handlers: make(byPriority, 0, 10)}
-	currentMockHandler = NoopHandler
-	defer func() { currentMockery = nil }()
-	configFunc()
-	sort.Stable(currentMockery.handlers)
-	return currentMockery
+// mock handler will be returned.  Mockery must not be used while another Mockery is being configured, either within
+// its config function or concurrently, it panics with the ConfigErrors instead.  Use New to build mocks concurrently.
+func Mockery(configFunc func()) Mock {
+	b, err := configureCurrent(configFunc)
+	if err != nil {
+		panic(err)
+	}
+	return b.Build()
+}
+
+// MockeryE is like Mockery but instead of panicking on the first problem with the configuration it carries on, so that
+// every problem is found, and returns them as ConfigErrors.  Each error is located at the file and line of the DSL
+// element with the problem, e.g.
+//
+//    mock, err := httpmock.MockeryE(func() {
+//      httpmock.Endpoint("/foo", func() {
+//        httpmock.Method("GET", func() {
+//          httpmock.RespondWithString(200, "bar")
+//          httpmock.FixedDelay("10 seconds")
+//        })
+//      })
+//    })
+//    if err != nil {
+//      // mocks_test.go:23(...): invalid delay for FixedDelay: time: unknown unit " seconds" in duration "10 seconds"
+//      log.Fatal(err)
+//    }
+//
+func MockeryE(configFunc func()) (Mock, error) {
+	b, err := configureCurrent(configFunc)
+	if err != nil {
+		return nil, err
+	}
+	return b.BuildE()
 }
 
 // CurrentHandler returns the current handler that should be decorated with any additional behaviors.
 func CurrentHandler() http.Handler {
-	return currentMockHandler
+	return CurrentBuilder().CurrentHandler()
+}
+
+// CurrentHandler returns the current handler that should be decorated with any additional behaviors.
+func (b *Builder) CurrentHandler() http.Handler {
+	return b.handler
 }
 
 // NoopHandler is a handler that does nothing.
@@ -78,15 +543,19 @@ var NoopHandler http.HandlerFunc = func(w http.ResponseWriter, request *http.Req
 // Header(string,string) function adds a preHandler that adds a Header to the ResponseWriter.  To use this function
 // to create new DSL Methods, follow this pattern:
 //
-//    func Header(name, value string) {
-//      return DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
-//				w.Header.Add(name,value)
-//    	}), NoopHandler)
-//    }
-//
+//	   func Header(name, value string) {
+//	     return DecorateHandler(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+//					w.Header.Add(name,value)
+//	   	}), NoopHandler)
+//	   }
 func DecorateHandler(preHandler, postHandler http.Handler) {
-	delegate := CurrentHandler()
-	currentMockHandler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+	CurrentBuilder().DecorateHandler(preHandler, postHandler)
+}
+
+// DecorateHandler is the Builder equivalent of the DecorateHandler function.
+func (b *Builder) DecorateHandler(preHandler, postHandler http.Handler) {
+	delegate := b.handler
+	b.handler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
 		preHandler.ServeHTTP(w, request)
 		delegate.ServeHTTP(w, request)
 		postHandler.ServeHTTP(w, request)
@@ -95,10 +564,20 @@ func DecorateHandler(preHandler, postHandler http.Handler) {
 
 // DecorateHandlerBefore is like DecorateHandler but only applies a Decoration before the handler is called.
 func DecorateHandlerBefore(preHandler http.Handler) {
-	DecorateHandler(preHandler, NoopHandler)
+	CurrentBuilder().DecorateHandlerBefore(preHandler)
+}
+
+// DecorateHandlerBefore is like DecorateHandler but only applies a Decoration before the handler is called.
+func (b *Builder) DecorateHandlerBefore(preHandler http.Handler) {
+	b.DecorateHandler(preHandler, NoopHandler)
 }
 
 // DecorateHandlerAfter is like DecorateHandler but only applies a Decoration after the handler is called.
 func DecorateHandlerAfter(postHandler http.Handler) {
-	DecorateHandler(NoopHandler, postHandler)
+	CurrentBuilder().DecorateHandlerAfter(postHandler)
+}
+
+// DecorateHandlerAfter is like DecorateHandler but only applies a Decoration after the handler is called.
+func (b *Builder) DecorateHandlerAfter(postHandler http.Handler) {
+	b.DecorateHandler(NoopHandler, postHandler)
 }
@@ -91,11 +91,11 @@ func TestDecorateHandler(t *testing.T) {
 	preCounter := countingHandler(0)
 	counter := countingHandler(0)
 	postCounter := countingHandler(0)
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	DecorateHandler(&preCounter, &postCounter)
 	mockWriter := httptest.NewRecorder()
 	mockRequest := httptest.NewRequest("GET", "/foo/bar/snafu", nil)
-	currentMockHandler.ServeHTTP(mockWriter, mockRequest)
+	CurrentHandler().ServeHTTP(mockWriter, mockRequest)
 	assert.Equal(t, 1, int(preCounter))
 	assert.Equal(t, 1, int(counter))
 	assert.Equal(t, 1, int(postCounter))
@@ -104,11 +104,11 @@ func TestDecorateHandler(t *testing.T) {
 func TestDecorateHandlerBefore(t *testing.T) {
 	preCounter := countingHandler(0)
 	counter := countingHandler(0)
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	DecorateHandlerBefore(&preCounter)
 	mockWriter := httptest.NewRecorder()
 	mockRequest := httptest.NewRequest("GET", "/foo/bar/snafu", nil)
-	currentMockHandler.ServeHTTP(mockWriter, mockRequest)
+	CurrentHandler().ServeHTTP(mockWriter, mockRequest)
 	assert.Equal(t, 1, int(preCounter))
 	assert.Equal(t, 1, int(counter))
 }
@@ -116,11 +116,11 @@ func TestDecorateHandlerBefore(t *testing.T) {
 func TestDecorateHandlerAfter(t *testing.T) {
 	postCounter := countingHandler(0)
 	counter := countingHandler(0)
-	currentMockHandler = &counter
+	useTestBuilder(t, &counter)
 	DecorateHandlerAfter(&postCounter)
 	mockWriter := httptest.NewRecorder()
 	mockRequest := httptest.NewRequest("GET", "/foo/bar/snafu", nil)
-	currentMockHandler.ServeHTTP(mockWriter, mockRequest)
+	CurrentHandler().ServeHTTP(mockWriter, mockRequest)
 	assert.Equal(t, 1, int(postCounter))
 	assert.Equal(t, 1, int(counter))
 }
@@ -0,0 +1,8 @@
+// Package openapi defines mockery endpoints from OpenAPI 3 documents.  OpenAPIEndpoints defines an endpoint for each
+// path template of a document, written in JSON or YAML, and responds to each operation with the example the document
+// gives for the response or, if there is none, with data generated from the response's schema.  Which response an
+// operation sends is chosen by a StatusStrategy.  References are supported within the document, to its components,
+// but not to other documents.  The keywords used to generate data are "example", "default", "enum", "type",
+// "format", "properties", "items", "minItems", "minimum", "maximum", "minLength", "allOf", "oneOf" and "anyOf".
+// A Spec is also a httpmock.RequestValidator, so httpmock.ValidateRequestAgainst can check requests against it.
+package openapi
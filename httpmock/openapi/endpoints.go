@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"github.com/bluesoftdev/go-http-matchers/extractor"
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"github.com/bluesoftdev/mockery/httpmock"
+
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatusStrategy chooses the response an operation sends to a request.  It is given the keys of the operation's
+// responses, e.g. "200", "404", "default", ordered by status with "default" last, and returns one of them.
+type StatusStrategy func(request *http.Request, statuses []string) string
+
+// FirstSuccess chooses the lowest 2xx status the operation has, then "2XX", then "default", and otherwise the lowest
+// status.  It is the strategy used by OpenAPIEndpoints.
+func FirstSuccess(request *http.Request, statuses []string) string {
+	for _, status := range statuses {
+		if strings.HasPrefix(status, "2") {
+			return status
+		}
+	}
+	for _, status := range statuses {
+		if status == "default" {
+			return status
+		}
+	}
+	if len(statuses) == 0 {
+		return ""
+	}
+	return statuses[0]
+}
+
+// PreferStatus returns a strategy that chooses the first of the statuses given that the operation has, e.g.
+// PreferStatus("201", "200"), and otherwise uses FirstSuccess.
+func PreferStatus(preferred ...string) StatusStrategy {
+	return func(request *http.Request, statuses []string) string {
+		for _, p := range preferred {
+			for _, status := range statuses {
+				if strings.EqualFold(p, status) {
+					return status
+				}
+			}
+		}
+		return FirstSuccess(request, statuses)
+	}
+}
+
+// StatusFromHeader returns a strategy that chooses the status named by the request header given, so that a test can
+// ask for an error response, e.g. "X-Mock-Status: 404".  If the header is missing, or names a status the operation
+// does not have, the fallback strategy is used.
+func StatusFromHeader(name string, fallback StatusStrategy) StatusStrategy {
+	return func(request *http.Request, statuses []string) string {
+		if wanted := request.Header.Get(name); wanted != "" {
+			for _, status := range statuses {
+				if strings.EqualFold(wanted, status) {
+					return status
+				}
+			}
+		}
+		return fallback(request, statuses)
+	}
+}
+
+// OpenAPIEndpoints loads the OpenAPI 3 document in specFile and defines an EndpointPattern for each of its path
+// templates, below the path of the document's first server, with a Method for each operation.  The operations respond
+// with the response chosen by FirstSuccess, using the example the document gives for it or data generated from its
+// schema, e.g.
+//
+//    mock := httpmock.Mockery(func() {
+//      openapi.OpenAPIEndpoints("./petstore.yaml")
+//    })
+//
+func OpenAPIEndpoints(specFile string) {
+	AddOpenAPIEndpointsWithStrategy(httpmock.CurrentBuilder(), specFile, FirstSuccess)
+}
+
+// OpenAPIEndpointsWithStrategy is like OpenAPIEndpoints but the responses are chosen by the strategy given, e.g.
+//
+//    openapi.OpenAPIEndpointsWithStrategy("./petstore.yaml", openapi.StatusFromHeader("X-Mock-Status", openapi.FirstSuccess))
+//
+func OpenAPIEndpointsWithStrategy(specFile string, strategy StatusStrategy) {
+	AddOpenAPIEndpointsWithStrategy(httpmock.CurrentBuilder(), specFile, strategy)
+}
+
+// AddOpenAPIEndpoints is like OpenAPIEndpoints but adds the endpoints to the given builder.
+func AddOpenAPIEndpoints(b *httpmock.Builder, specFile string) {
+	AddOpenAPIEndpointsWithStrategy(b, specFile, FirstSuccess)
+}
+
+// AddOpenAPIEndpointsWithStrategy is like OpenAPIEndpointsWithStrategy but adds the endpoints to the given builder.
+func AddOpenAPIEndpointsWithStrategy(b *httpmock.Builder, specFile string, strategy StatusStrategy) {
+	spec, err := LoadSpec(specFile)
+	if err != nil {
+		b.Errorf("loading OpenAPI spec: %s", err.Error())
+		return
+	}
+	AddSpecEndpoints(b, spec, strategy)
+}
+
+// SpecEndpoints defines the endpoints of a document loaded by LoadSpec, as OpenAPIEndpointsWithStrategy does.
+func SpecEndpoints(spec *Spec, strategy StatusStrategy) {
+	AddSpecEndpoints(httpmock.CurrentBuilder(), spec, strategy)
+}
+
+// AddSpecEndpoints is like SpecEndpoints but adds the endpoints to the given builder.
+func AddSpecEndpoints(b *httpmock.Builder, spec *Spec, strategy StatusStrategy) {
+	for _, path := range spec.paths {
+		b.DefinedAt(spec.File+"#/paths/"+escape(path.template), func() {
+			b.EndpointPattern(pathPattern(spec.BasePath+path.template, "[^/]+"), func() {
+				b.Switch(extractor.ExtractMethod(), func() {
+					operations := path.item.operations()
+					for _, method := range sortedKeys(operations) {
+						op := operations[method]
+						b.Method(method, func() {
+							operationResponseConfig(b, op, strategy)
+						})
+					}
+				})
+			})
+		})
+	}
+}
+
+var pathParameterPattern = regexp.MustCompile(`\{[^}/]*\}`)
+
+// pathPattern returns the regular expression matching the paths of a path template, with the parameters replaced by
+// the pattern given, e.g. "^/pets/[^/]+$" for "/pets/{petId}".
+func pathPattern(template, parameter string) string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range pathParameterPattern.FindAllStringIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString(parameter)
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+func operationResponseConfig(b *httpmock.Builder, op *operation, strategy StatusStrategy) {
+	statuses := sortedStatuses(op.Responses)
+	if len(statuses) == 0 {
+		b.Errorf("the operation has no responses")
+		return
+	}
+	b.Switch(extractor.ExtractorFunc(func(r interface{}) interface{} {
+		return strategy(r.(*http.Request), statuses)
+	}), func() {
+		for _, status := range statuses {
+			resp := op.Responses[status]
+			code := statusCode(status, len(statuses))
+			b.Case(httpmock.Describe("response is "+status, predicate.StringEquals(status)), func() {
+				responseConfig(b, code, resp)
+			})
+		}
+	})
+}
+
+// sortedStatuses returns the keys of the responses ordered by status, the ranges, e.g. "4XX", after the statuses in
+// them and "default" last.
+func sortedStatuses(responses map[string]*response) []string {
+	statuses := sortedKeys(responses)
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statusOrder(statuses[i]) < statusOrder(statuses[j])
+	})
+	return statuses
+}
+
+func statusOrder(status string) float64 {
+	if code, err := strconv.Atoi(status); err == nil {
+		return float64(code)
+	}
+	if len(status) == 3 && strings.EqualFold(status[1:], "XX") && status[0] >= '1' && status[0] <= '5' {
+		return float64(status[0]-'0')*100 + 99.5
+	}
+	return 1000
+}
+
+// statusCode returns the status code to respond with for a response key, the lowest of a range, e.g. 400 for "4XX",
+// and for "default" 200 if it is the only response, 500 otherwise.
+func statusCode(status string, responses int) int {
+	if code, err := strconv.Atoi(status); err == nil {
+		return code
+	}
+	if order := statusOrder(status); order < 1000 {
+		return int(order/100) * 100
+	}
+	if responses == 1 {
+		return http.StatusOK
+	}
+	return http.StatusInternalServerError
+}
+
+func responseConfig(b *httpmock.Builder, code int, resp *response) {
+	if resp == nil {
+		b.Respond(code)
+		return
+	}
+	for _, name := range sortedKeys(resp.Headers) {
+		h := resp.Headers[name]
+		if h == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		if value := exampleValue(h.Example, h.Examples, h.Schema); value != nil {
+			b.Header(name, fmt.Sprintf("%v", value))
+		}
+	}
+	contentType := chooseContentType(resp.Content)
+	if contentType == "" {
+		b.Respond(code)
+		return
+	}
+	media := resp.Content[contentType]
+	body, err := encodeBody(contentType, exampleValue(media.Example, media.Examples, media.Schema))
+	if err != nil {
+		b.Errorf("encoding the %s example: %s", contentType, err.Error())
+		return
+	}
+	b.Header("Content-Type", contentType)
+	b.RespondWithString(code, body)
+}
+
+// chooseContentType returns "application/json" if the response has it, otherwise another JSON content type or the
+// first content type.
+func chooseContentType(content map[string]*mediaType) string {
+	contentTypes := make([]string, 0, len(content))
+	for _, contentType := range sortedKeys(content) {
+		if content[contentType] != nil {
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+	if len(contentTypes) == 0 {
+		return ""
+	}
+	for _, contentType := range contentTypes {
+		if contentType == "application/json" {
+			return contentType
+		}
+	}
+	for _, contentType := range contentTypes {
+		if isJSON(contentType) {
+			return contentType
+		}
+	}
+	return contentTypes[0]
+}
+
+func isJSON(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json")
+}
+
+// encodeBody encodes the value as JSON, unless it is a string and the content type is not JSON.
+func encodeBody(contentType string, value interface{}) (string, error) {
+	if s, ok := value.(string); ok && !isJSON(contentType) {
+		return s, nil
+	}
+	if value == nil && !isJSON(contentType) {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
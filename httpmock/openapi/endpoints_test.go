@@ -0,0 +1,116 @@
+package openapi_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/openapi"
+
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func serve(mock Mock, method, url string, headers ...string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, url, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, request)
+	return w
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	mock := Mockery(func() {
+		OpenAPIEndpoints("./testdata/petstore.yaml")
+	})
+
+	w := serve(mock, "GET", "/v1/pets?limit=2")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	assert.JSONEq(t, `[{"id": 1, "name": "Rex"}, {"id": 2, "name": "Tom"}]`, w.Body.String())
+
+	w = serve(mock, "POST", "/v1/pets")
+	assert.Equal(t, 201, w.Code)
+	assert.JSONEq(t, `{"id": 1, "name": "Rex", "tag": "dog", "born": "2020-01-01"}`, w.Body.String())
+
+	w = serve(mock, "GET", "/v1/pets/7")
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"id": 1, "name": "Rex", "tag": "dog", "born": "2020-01-01"}`, w.Body.String())
+
+	w = serve(mock, "DELETE", "/v1/pets/7")
+	assert.Equal(t, 204, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serve(mock, "GET", "/v1/pets/mine")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Fido", w.Body.String())
+
+	assert.Equal(t, 404, serve(mock, "PUT", "/v1/pets/7").Code)
+	assert.Equal(t, 404, serve(mock, "GET", "/pets").Code)
+	assert.Equal(t, 404, serve(mock, "GET", "/v1/pets/7/toys").Code)
+
+	requests := mock.Requests()
+	if assert.Len(t, requests, 8) {
+		assert.Equal(t, `^/v1/pets/[^/]+$`, requests[2].Endpoint)
+		assert.Equal(t, `^/v1/pets/mine$`, requests[4].Endpoint)
+	}
+}
+
+func TestOpenAPIEndpointsWithStrategy(t *testing.T) {
+	mock := Mockery(func() {
+		OpenAPIEndpointsWithStrategy("./testdata/petstore.yaml", StatusFromHeader("X-Mock-Status", PreferStatus("4XX")))
+	})
+
+	w := serve(mock, "GET", "/v1/pets/7", "X-Mock-Status", "404")
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code": 0, "message": "something went wrong"}`, w.Body.String())
+
+	w = serve(mock, "GET", "/v1/pets", "X-Mock-Status", "default")
+	assert.Equal(t, 500, w.Code)
+	assert.JSONEq(t, `{"code": 0, "message": "something went wrong"}`, w.Body.String())
+
+	assert.Equal(t, 200, serve(mock, "GET", "/v1/pets/7", "X-Mock-Status", "503").Code)
+	assert.Equal(t, 400, serve(mock, "POST", "/v1/pets").Code)
+}
+
+func TestOpenAPIEndpointsJSON(t *testing.T) {
+	mock := Mockery(func() {
+		OpenAPIEndpoints("./testdata/orders.json")
+	})
+
+	w := serve(mock, "GET", "/orders/42/lines")
+	assert.Equal(t, 200, w.Code)
+	leaf := `"sku": "3fa85f64-5717-4562-b3fc-2c963f66afa6", "quantity": 1, "price": 0, "gift": true`
+	line := `{` + leaf + `, "parts": [{` + leaf + `, "parts": [{}]}]}`
+	assert.JSONEq(t, `[`+line+`, `+line+`]`, w.Body.String())
+}
+
+func TestLoadSpecErrors(t *testing.T) {
+	_, err := LoadSpec("./testdata/missing.yaml")
+	assert.Error(t, err)
+
+	_, err = LoadSpec("./testdata/swagger.yaml")
+	if assert.Error(t, err) {
+		assert.Equal(t, `./testdata/swagger.yaml is not an OpenAPI 3 document, its openapi version is ""`, err.Error())
+	}
+
+	_, err = LoadSpec("./testdata/broken.yaml")
+	if assert.Error(t, err) {
+		assert.Equal(t,
+			"./testdata/broken.yaml: #/components/schemas/A: $ref \"#/components/schemas/B\" is circular\n"+
+				"./testdata/broken.yaml: #/components/schemas/C/pattern: invalid pattern \"[a-z\": error parsing regexp: missing closing ]: `[a-z`\n"+
+				"./testdata/broken.yaml: #/paths/~1things/get/responses/200: $ref \"#/components/responses/Missing\" refers to a component that is not defined\n"+
+				"./testdata/broken.yaml: #/paths/~1things/get/responses/400: unsupported $ref \"errors.yaml#/Error\", only references to #/components/responses are supported",
+			err.Error())
+	}
+
+	_, err = MockeryE(func() {
+		OpenAPIEndpoints("./testdata/broken.yaml")
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "loading OpenAPI spec: ./testdata/broken.yaml: ")
+	}
+}
@@ -0,0 +1,145 @@
+package openapi
+
+import (
+	"math"
+	"strings"
+)
+
+// maxExampleDepth is how deep generated objects and arrays are nested, so that recursive schemas end.
+const maxExampleDepth = 5
+
+// exampleValue returns the example given for a media type, parameter or header: the example, the first of the named
+// examples or one generated from the schema.
+func exampleValue(value interface{}, examples map[string]*example, s *schema) interface{} {
+	if value != nil {
+		return value
+	}
+	for _, name := range sortedKeys(examples) {
+		if e := examples[name]; e != nil && e.Value != nil {
+			return e.Value
+		}
+	}
+	return generate(s, 0)
+}
+
+// generate returns a value that is valid for the schema, it uses the schema's example, default or first enum value if
+// it has one.
+func generate(s *schema, depth int) interface{} {
+	switch {
+	case s == nil:
+		return nil
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		return generateAllOf(s, depth)
+	case len(s.OneOf) > 0:
+		return generate(s.OneOf[0], depth)
+	case len(s.AnyOf) > 0:
+		return generate(s.AnyOf[0], depth)
+	}
+	switch s.Type.primary() {
+	case "object":
+		return generateObject(s, depth)
+	case "array":
+		return generateArray(s, depth)
+	case "string":
+		return generateString(s)
+	case "integer":
+		return int64(math.Ceil(generateNumber(s)))
+	case "number":
+		return generateNumber(s)
+	case "boolean":
+		return true
+	case "":
+		if s.Properties != nil {
+			return generateObject(s, depth)
+		}
+	}
+	return nil
+}
+
+func generateObject(s *schema, depth int) interface{} {
+	object := make(map[string]interface{}, len(s.Properties))
+	if depth >= maxExampleDepth {
+		return object
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		object[name] = generate(s.Properties[name], depth+1)
+	}
+	return object
+}
+
+// generateAllOf merges the objects generated for each of the schemas, if they are not objects the value generated for
+// the last schema is used.
+func generateAllOf(s *schema, depth int) interface{} {
+	var value interface{}
+	merged := make(map[string]interface{})
+	for _, part := range s.AllOf {
+		value = generate(part, depth)
+		if object, ok := value.(map[string]interface{}); ok {
+			for name, v := range object {
+				merged[name] = v
+			}
+			value = merged
+		}
+	}
+	return value
+}
+
+func generateArray(s *schema, depth int) interface{} {
+	n := 1
+	if s.MinItems != nil {
+		n = *s.MinItems
+	} else if depth >= maxExampleDepth || (s.MaxItems != nil && *s.MaxItems == 0) {
+		n = 0
+	}
+	array := make([]interface{}, n)
+	for i := range array {
+		array[i] = generate(s.Items, depth+1)
+	}
+	return array
+}
+
+// formatExamples are the strings generated for the string formats.
+var formatExamples = map[string]string{
+	"date":      "2020-01-01",
+	"date-time": "2020-01-01T00:00:00Z",
+	"time":      "00:00:00Z",
+	"email":     "user@example.com",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"byte":      "c3RyaW5n",
+}
+
+func generateString(s *schema) string {
+	value, ok := formatExamples[s.Format]
+	if !ok {
+		value = "string"
+	}
+	if s.MinLength != nil && len(value) < *s.MinLength {
+		value += strings.Repeat("x", *s.MinLength-len(value))
+	}
+	if s.MaxLength != nil && len(value) > *s.MaxLength {
+		value = value[:*s.MaxLength]
+	}
+	return value
+}
+
+// generateNumber returns 0 unless it is outside the minimum or maximum, in which case it returns the one it is outside.
+func generateNumber(s *schema) float64 {
+	switch {
+	case s.Minimum != nil && *s.Minimum > 0:
+		return *s.Minimum
+	case s.Maximum != nil && *s.Maximum < 0:
+		return *s.Maximum
+	}
+	return 0
+}
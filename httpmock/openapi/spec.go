@@ -0,0 +1,539 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Spec is an OpenAPI 3 document loaded by LoadSpec, with its references resolved.
+type Spec struct {
+	// File is the file the document was loaded from.
+	File string
+	// BasePath is the path of the document's first server, e.g. "/v1" for "https://api.example.com/v1", which the
+	// path templates are relative to.
+	BasePath string
+
+	doc   *document
+	paths []*specPath
+}
+
+// specPath is a path template of the document with the regular expression that matches its paths, which has a group
+// for each of the path parameters named.
+type specPath struct {
+	template   string
+	pattern    *regexp.Regexp
+	parameters []string
+	item       *pathItem
+}
+
+type document struct {
+	OpenAPI    string               `json:"openapi"`
+	Servers    []*server            `json:"servers"`
+	Paths      map[string]*pathItem `json:"paths"`
+	Components components           `json:"components"`
+}
+
+type server struct {
+	URL       string                     `json:"url"`
+	Variables map[string]*serverVariable `json:"variables"`
+}
+
+type serverVariable struct {
+	Default string `json:"default"`
+}
+
+type components struct {
+	Schemas       map[string]*schema      `json:"schemas"`
+	Responses     map[string]*response    `json:"responses"`
+	Parameters    map[string]*parameter   `json:"parameters"`
+	RequestBodies map[string]*requestBody `json:"requestBodies"`
+	Headers       map[string]*header      `json:"headers"`
+	Examples      map[string]*example     `json:"examples"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `json:"parameters"`
+	Get        *operation   `json:"get"`
+	Put        *operation   `json:"put"`
+	Post       *operation   `json:"post"`
+	Delete     *operation   `json:"delete"`
+	Options    *operation   `json:"options"`
+	Head       *operation   `json:"head"`
+	Patch      *operation   `json:"patch"`
+	Trace      *operation   `json:"trace"`
+}
+
+// operations returns the operations of the path by method, e.g. "GET".
+func (p *pathItem) operations() map[string]*operation {
+	ops := make(map[string]*operation, 8)
+	for method, op := range map[string]*operation{"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch, "TRACE": p.Trace} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Ref      string              `json:"$ref"`
+	Name     string              `json:"name"`
+	In       string              `json:"in"`
+	Required bool                `json:"required"`
+	Schema   *schema             `json:"schema"`
+	Example  interface{}         `json:"example"`
+	Examples map[string]*example `json:"examples"`
+}
+
+type requestBody struct {
+	Ref      string                `json:"$ref"`
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Ref     string                `json:"$ref"`
+	Headers map[string]*header    `json:"headers"`
+	Content map[string]*mediaType `json:"content"`
+}
+
+type header struct {
+	Ref      string              `json:"$ref"`
+	Schema   *schema             `json:"schema"`
+	Example  interface{}         `json:"example"`
+	Examples map[string]*example `json:"examples"`
+}
+
+type mediaType struct {
+	Schema   *schema             `json:"schema"`
+	Example  interface{}         `json:"example"`
+	Examples map[string]*example `json:"examples"`
+}
+
+type example struct {
+	Ref   string      `json:"$ref"`
+	Value interface{} `json:"value"`
+}
+
+type schema struct {
+	Ref                    string             `json:"$ref"`
+	Type                   schemaType         `json:"type"`
+	Format                 string             `json:"format"`
+	Nullable               bool               `json:"nullable"`
+	Enum                   []interface{}      `json:"enum"`
+	Default                interface{}        `json:"default"`
+	Example                interface{}        `json:"example"`
+	Properties             map[string]*schema `json:"properties"`
+	Required               []string           `json:"required"`
+	AdditionalProperties   *schema            `json:"-"`
+	NoAdditionalProperties bool               `json:"-"`
+	Items                  *schema            `json:"items"`
+	MinItems               *int               `json:"minItems"`
+	MaxItems               *int               `json:"maxItems"`
+	Minimum                *float64           `json:"minimum"`
+	Maximum                *float64           `json:"maximum"`
+	MinLength              *int               `json:"minLength"`
+	MaxLength              *int               `json:"maxLength"`
+	Pattern                string             `json:"pattern"`
+	AllOf                  []*schema          `json:"allOf"`
+	OneOf                  []*schema          `json:"oneOf"`
+	AnyOf                  []*schema          `json:"anyOf"`
+	// compiledPattern is the Pattern compiled by LoadSpec.
+	compiledPattern *regexp.Regexp
+}
+
+// UnmarshalJSON decodes the schema, "additionalProperties" may be a schema or a boolean.
+func (s *schema) UnmarshalJSON(data []byte) error {
+	type plainSchema schema
+	var decoded struct {
+		*plainSchema
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	decoded.plainSchema = (*plainSchema)(s)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	switch strings.TrimSpace(string(decoded.AdditionalProperties)) {
+	case "", "null", "true":
+	case "false":
+		s.NoAdditionalProperties = true
+	default:
+		s.AdditionalProperties = &schema{}
+		return json.Unmarshal(decoded.AdditionalProperties, s.AdditionalProperties)
+	}
+	return nil
+}
+
+// schemaType is the "type" of a schema, OpenAPI 3.1 allows a list of types, e.g. ["string", "null"].
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = schemaType{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = names
+	return nil
+}
+
+// is returns whether the type is, or includes, the name given.
+func (t schemaType) is(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// primary returns the type other than "null", or "" if the schema does not have one.
+func (t schemaType) primary() string {
+	for _, n := range t {
+		if n != "null" {
+			return n
+		}
+	}
+	return ""
+}
+
+// LoadSpec loads an OpenAPI 3 document, written in JSON or YAML, and resolves its references.  The error lists every
+// reference that could not be resolved and every schema pattern that is not a valid regular expression.
+func LoadSpec(fileName string) (*Spec, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	// As JSON is a subset of YAML the YAML parser reads both.
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", fileName, err.Error())
+	}
+	data, err = json.Marshal(jsonValue(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", fileName, err.Error())
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", fileName, err.Error())
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s is not an OpenAPI 3 document, its openapi version is %q", fileName, doc.OpenAPI)
+	}
+	r := &resolver{components: &doc.Components, schemas: make(map[*schema]*schema)}
+	r.resolve(&doc)
+	if len(r.errs) > 0 {
+		return nil, fmt.Errorf("%s: %s", fileName, strings.Join(r.errs, "\n"+fileName+": "))
+	}
+	spec := &Spec{File: fileName, BasePath: basePath(doc.Servers), doc: &doc}
+	for _, template := range spec.sortedPaths() {
+		if item := doc.Paths[template]; item != nil {
+			spec.paths = append(spec.paths, newSpecPath(template, item))
+		}
+	}
+	return spec, nil
+}
+
+func newSpecPath(template string, item *pathItem) *specPath {
+	p := &specPath{template: template, item: item}
+	for _, parameter := range pathParameterPattern.FindAllString(template, -1) {
+		p.parameters = append(p.parameters, parameter[1:len(parameter)-1])
+	}
+	p.pattern = regexp.MustCompile(pathPattern(template, "([^/]+)"))
+	return p
+}
+
+// jsonValue converts the maps produced by the YAML parser, whose keys are interface{}, into maps that can be encoded
+// as JSON.
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprintf("%v", k)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	}
+	return v
+}
+
+// basePath returns the path of the first server's URL, with its variables replaced by their defaults.
+func basePath(servers []*server) string {
+	if len(servers) == 0 {
+		return ""
+	}
+	s := servers[0].URL
+	for name, variable := range servers[0].Variables {
+		s = strings.Replace(s, "{"+name+"}", variable.Default, -1)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(u.Path, "/")
+}
+
+// sortedPaths returns the path templates of the document, those with fewer parameters first, so that a concrete path
+// such as "/pets/mine" is considered before "/pets/{petId}" as OpenAPI requires.
+func (s *Spec) sortedPaths() []string {
+	paths := make([]string, 0, len(s.doc.Paths))
+	for path := range s.doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		pi, pj := strings.Count(paths[i], "{"), strings.Count(paths[j], "{")
+		if pi != pj {
+			return pi < pj
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// resolver replaces the references in a document with the components they refer to.
+type resolver struct {
+	components *components
+	schemas    map[*schema]*schema
+	errs       []string
+}
+
+// resolving marks a schema reference that is being resolved, so that circular references are found.
+var resolving = &schema{}
+
+func (r *resolver) errorf(path, format string, args ...interface{}) {
+	r.errs = append(r.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// lookup returns the name of the component, of the kind given, e.g. "schemas", that the reference refers to and
+// whether it is one of the components, a map by name.
+func (r *resolver) lookup(path, ref, kind string, components interface{}) (string, bool) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		r.errorf(path, "unsupported $ref %q, only references to #/components/%s are supported", ref, kind)
+		return "", false
+	}
+	name := strings.Replace(strings.Replace(ref[len(prefix):], "~1", "/", -1), "~0", "~", -1)
+	c := reflect.ValueOf(components).MapIndex(reflect.ValueOf(name))
+	if !c.IsValid() || c.IsNil() {
+		r.errorf(path, "$ref %q refers to a component that is not defined", ref)
+		return "", false
+	}
+	return name, true
+}
+
+func (r *resolver) resolve(doc *document) {
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		doc.Components.Schemas[name] = r.schema("#/components/schemas/"+escape(name), doc.Components.Schemas[name])
+	}
+	for _, path := range sortedKeys(doc.Paths) {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		itemPath := "#/paths/" + escape(path)
+		for i, p := range item.Parameters {
+			item.Parameters[i] = r.parameter(fmt.Sprintf("%s/parameters/%d", itemPath, i), p)
+		}
+		operations := item.operations()
+		for _, method := range sortedKeys(operations) {
+			op := operations[method]
+			opPath := itemPath + "/" + strings.ToLower(method)
+			for i, p := range op.Parameters {
+				op.Parameters[i] = r.parameter(fmt.Sprintf("%s/parameters/%d", opPath, i), p)
+			}
+			op.Parameters = mergeParameters(item.Parameters, op.Parameters)
+			op.RequestBody = r.requestBody(opPath+"/requestBody", op.RequestBody)
+			for _, status := range sortedKeys(op.Responses) {
+				op.Responses[status] = r.response(opPath+"/responses/"+status, op.Responses[status])
+			}
+		}
+	}
+}
+
+// mergeParameters returns the parameters of an operation with those of its path that it does not override.
+func mergeParameters(pathParameters, opParameters []*parameter) []*parameter {
+	merged := make([]*parameter, 0, len(pathParameters)+len(opParameters))
+	for _, p := range opParameters {
+		if p != nil {
+			merged = append(merged, p)
+		}
+	}
+	for _, p := range pathParameters {
+		overridden := p == nil
+		for _, op := range merged {
+			if p != nil && op.Name == p.Name && op.In == p.In {
+				overridden = true
+			}
+		}
+		if !overridden {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+func (r *resolver) schema(path string, s *schema) *schema {
+	if s == nil {
+		return nil
+	}
+	if resolved, ok := r.schemas[s]; ok {
+		if resolved == resolving {
+			r.errorf(path, "$ref %q is circular", s.Ref)
+			return nil
+		}
+		return resolved
+	}
+	if s.Ref != "" {
+		name, ok := r.lookup(path, s.Ref, "schemas", r.components.Schemas)
+		if !ok {
+			r.schemas[s] = nil
+			return nil
+		}
+		r.schemas[s] = resolving
+		r.schemas[s] = r.schema("#/components/schemas/"+escape(name), r.components.Schemas[name])
+		return r.schemas[s]
+	}
+	r.schemas[s] = s
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			r.errorf(path+"/pattern", "invalid pattern %q: %s", s.Pattern, err.Error())
+		}
+		s.compiledPattern = pattern
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		s.Properties[name] = r.schema(path+"/properties/"+escape(name), s.Properties[name])
+	}
+	s.AdditionalProperties = r.schema(path+"/additionalProperties", s.AdditionalProperties)
+	s.Items = r.schema(path+"/items", s.Items)
+	for i := range s.AllOf {
+		s.AllOf[i] = r.schema(fmt.Sprintf("%s/allOf/%d", path, i), s.AllOf[i])
+	}
+	for i := range s.OneOf {
+		s.OneOf[i] = r.schema(fmt.Sprintf("%s/oneOf/%d", path, i), s.OneOf[i])
+	}
+	for i := range s.AnyOf {
+		s.AnyOf[i] = r.schema(fmt.Sprintf("%s/anyOf/%d", path, i), s.AnyOf[i])
+	}
+	return s
+}
+
+func (r *resolver) parameter(path string, p *parameter) *parameter {
+	if p != nil && p.Ref != "" {
+		name, ok := r.lookup(path, p.Ref, "parameters", r.components.Parameters)
+		if !ok {
+			return nil
+		}
+		return r.parameter("#/components/parameters/"+escape(name), r.components.Parameters[name])
+	}
+	if p != nil {
+		p.Schema = r.schema(path+"/schema", p.Schema)
+		r.examples(path+"/examples", p.Examples)
+	}
+	return p
+}
+
+func (r *resolver) requestBody(path string, b *requestBody) *requestBody {
+	if b != nil && b.Ref != "" {
+		name, ok := r.lookup(path, b.Ref, "requestBodies", r.components.RequestBodies)
+		if !ok {
+			return nil
+		}
+		return r.requestBody("#/components/requestBodies/"+escape(name), r.components.RequestBodies[name])
+	}
+	if b != nil {
+		r.content(path+"/content", b.Content)
+	}
+	return b
+}
+
+func (r *resolver) response(path string, resp *response) *response {
+	if resp != nil && resp.Ref != "" {
+		name, ok := r.lookup(path, resp.Ref, "responses", r.components.Responses)
+		if !ok {
+			return nil
+		}
+		return r.response("#/components/responses/"+escape(name), r.components.Responses[name])
+	}
+	if resp != nil {
+		for _, name := range sortedKeys(resp.Headers) {
+			resp.Headers[name] = r.header(path+"/headers/"+escape(name), resp.Headers[name])
+		}
+		r.content(path+"/content", resp.Content)
+	}
+	return resp
+}
+
+func (r *resolver) header(path string, h *header) *header {
+	if h != nil && h.Ref != "" {
+		name, ok := r.lookup(path, h.Ref, "headers", r.components.Headers)
+		if !ok {
+			return nil
+		}
+		return r.header("#/components/headers/"+escape(name), r.components.Headers[name])
+	}
+	if h != nil {
+		h.Schema = r.schema(path+"/schema", h.Schema)
+		r.examples(path+"/examples", h.Examples)
+	}
+	return h
+}
+
+func (r *resolver) content(path string, content map[string]*mediaType) {
+	for _, contentType := range sortedKeys(content) {
+		if media := content[contentType]; media != nil {
+			media.Schema = r.schema(path+"/"+escape(contentType)+"/schema", media.Schema)
+			r.examples(path+"/"+escape(contentType)+"/examples", media.Examples)
+		}
+	}
+}
+
+func (r *resolver) examples(path string, examples map[string]*example) {
+	for _, name := range sortedKeys(examples) {
+		if e := examples[name]; e != nil && e.Ref != "" {
+			examples[name] = nil
+			if component, ok := r.lookup(path+"/"+escape(name), e.Ref, "examples", r.components.Examples); ok {
+				examples[name] = r.components.Examples[component]
+			}
+		}
+	}
+}
+
+// escape escapes a name for use in a JSON pointer, e.g. "/pets" becomes "~1pets".
+func escape(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
+
+// sortedKeys returns the keys of m, a map with string keys, in order.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+	}
+	sort.Strings(names)
+	return names
+}
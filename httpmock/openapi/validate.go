@@ -0,0 +1,321 @@
+package openapi
+
+import (
+	"github.com/bluesoftdev/mockery/httpmock"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateRequest checks the request against the operation of the document it is for: its path, query, header and
+// cookie parameters and, for JSON bodies, the schema of its body.  It implements httpmock.RequestValidator, so the
+// Spec can be used with httpmock.ValidateRequestAgainst.  The schema keywords checked are "type", "nullable", "enum",
+// "properties", "required", "additionalProperties", "items", "minItems", "maxItems", "minimum", "maximum",
+// "minLength", "maxLength", "pattern", "allOf", "oneOf" and "anyOf".
+func (s *Spec) ValidateRequest(request *http.Request) []httpmock.RequestViolation {
+	path := request.URL.Path
+	if path != s.BasePath && !strings.HasPrefix(path, s.BasePath+"/") {
+		return []httpmock.RequestViolation{{In: "path", Message: fmt.Sprintf("%s is not below %s", path, s.BasePath)}}
+	}
+	for _, p := range s.paths {
+		match := p.pattern.FindStringSubmatch(path[len(s.BasePath):])
+		if match == nil {
+			continue
+		}
+		op := p.item.operations()[request.Method]
+		if op == nil {
+			return []httpmock.RequestViolation{{In: "method",
+				Message: fmt.Sprintf("%s is not an operation of %s", request.Method, p.template)}}
+		}
+		pathValues := make(map[string]string, len(p.parameters))
+		for i, name := range p.parameters {
+			if value, err := url.PathUnescape(match[i+1]); err == nil {
+				pathValues[name] = value
+			} else {
+				pathValues[name] = match[i+1]
+			}
+		}
+		v := &requestValidation{}
+		v.parameters(op.Parameters, pathValues, request)
+		v.body(op.RequestBody, request)
+		return v.violations
+	}
+	return []httpmock.RequestViolation{{In: "path", Message: fmt.Sprintf("%s matches no path of the spec", path)}}
+}
+
+type requestValidation struct {
+	violations []httpmock.RequestViolation
+}
+
+func (v *requestValidation) add(in, name, message string) {
+	v.violations = append(v.violations, httpmock.RequestViolation{In: in, Name: name, Message: message})
+}
+
+func (v *requestValidation) parameters(parameters []*parameter, pathValues map[string]string, request *http.Request) {
+	query := request.URL.Query()
+	for _, p := range parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			if value, ok := pathValues[p.Name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = request.Header[http.CanonicalHeaderKey(p.Name)]
+		case "cookie":
+			if cookie, err := request.Cookie(p.Name); err == nil {
+				values = []string{cookie.Value}
+			}
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if p.Required || p.In == "path" {
+				v.add(p.In, p.Name, "is required")
+			}
+			continue
+		}
+		validateSchema(p.Schema, parameterValue(p, values), "", func(pointer, message string) {
+			v.add(p.In, p.Name+pointer, message)
+		})
+	}
+}
+
+// parameterValue converts the values of a parameter to the types its schema expects, so that they can be validated.
+// Values that can't be converted are left as strings for the validation to report.  The items of an array are the
+// values of a repeated query parameter, or the comma separated parts of other parameters.
+func parameterValue(p *parameter, values []string) interface{} {
+	if p.Schema == nil || p.Schema.Type.primary() != "array" {
+		return convert(p.Schema, values[0])
+	}
+	if p.In != "query" {
+		values = strings.Split(values[0], ",")
+	}
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		items[i] = convert(p.Schema.Items, value)
+	}
+	return items
+}
+
+func convert(s *schema, value string) interface{} {
+	if s == nil {
+		return value
+	}
+	switch s.Type.primary() {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func (v *requestValidation) body(body *requestBody, request *http.Request) {
+	if body == nil || request.Body == nil {
+		if body != nil && body.Required {
+			v.add("body", "", "is required")
+		}
+		return
+	}
+	data, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		v.add("body", "", err.Error())
+		return
+	}
+	if len(data) == 0 {
+		if body.Required {
+			v.add("body", "", "is required")
+		}
+		return
+	}
+	contentType := request.Header.Get("Content-Type")
+	media, ok := findMediaType(body.Content, contentType)
+	if !ok {
+		v.add("body", "", fmt.Sprintf("content type %q is not one of %s", contentType,
+			strings.Join(sortedKeys(body.Content), ", ")))
+		return
+	}
+	if media == nil || media.Schema == nil || !isJSON(contentType) {
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		v.add("body", "", "is not valid JSON: "+err.Error())
+		return
+	}
+	validateSchema(media.Schema, value, "", func(pointer, message string) {
+		v.add("body", pointer, message)
+	})
+}
+
+// findMediaType returns the media type of the content for the content type, a media type range such as "image/*" or
+// "*/*" matches the content types in it.
+func findMediaType(content map[string]*mediaType, contentType string) (*mediaType, bool) {
+	if len(content) == 0 {
+		return nil, true
+	}
+	name, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	ranges := []string{name, name[:strings.Index(name+"/", "/")] + "/*", "*/*"}
+	for _, r := range ranges {
+		for key, media := range content {
+			if key, _, err := mime.ParseMediaType(key); err == nil && key == r {
+				return media, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// validateSchema reports, with the JSON pointer of the part of the value, the ways in which the value, decoded from
+// JSON, does not meet the schema.
+func validateSchema(s *schema, value interface{}, pointer string, report func(pointer, message string)) {
+	if s == nil {
+		return
+	}
+	if value == nil {
+		if len(s.Type) > 0 && !s.Nullable && !s.Type.is("null") {
+			report(pointer, "must not be null")
+		}
+		return
+	}
+	for _, part := range s.AllOf {
+		validateSchema(part, value, pointer, report)
+	}
+	if len(s.AnyOf) > 0 && matching(s.AnyOf, value) == 0 {
+		report(pointer, "must match at least one of the anyOf schemas")
+	}
+	if len(s.OneOf) > 0 {
+		if n := matching(s.OneOf, value); n != 1 {
+			report(pointer, fmt.Sprintf("must match exactly one of the oneOf schemas but matches %d", n))
+		}
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		enum, _ := json.Marshal(s.Enum)
+		report(pointer, "must be one of "+string(enum))
+	}
+	if t := s.Type.primary(); typeDescriptions[t] != "" && !hasType(value, t) {
+		report(pointer, "must be "+typeDescriptions[t])
+		return
+	}
+	switch value := value.(type) {
+	case string:
+		validateString(s, value, pointer, report)
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			report(pointer, fmt.Sprintf("must be at least %v", *s.Minimum))
+		}
+		if s.Maximum != nil && value > *s.Maximum {
+			report(pointer, fmt.Sprintf("must be at most %v", *s.Maximum))
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			report(pointer, fmt.Sprintf("must have at least %d item(s)", *s.MinItems))
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			report(pointer, fmt.Sprintf("must have at most %d item(s)", *s.MaxItems))
+		}
+		for i, item := range value {
+			validateSchema(s.Items, item, fmt.Sprintf("%s/%d", pointer, i), report)
+		}
+	case map[string]interface{}:
+		validateObject(s, value, pointer, report)
+	}
+}
+
+func validateString(s *schema, value, pointer string, report func(pointer, message string)) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		report(pointer, fmt.Sprintf("must be at least %d character(s) long", *s.MinLength))
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		report(pointer, fmt.Sprintf("must be at most %d character(s) long", *s.MaxLength))
+	}
+	if s.compiledPattern != nil && !s.compiledPattern.MatchString(value) {
+		report(pointer, fmt.Sprintf("must match %q", s.Pattern))
+	}
+}
+
+func validateObject(s *schema, value map[string]interface{}, pointer string, report func(pointer, message string)) {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			report(pointer+"/"+escape(name), "is required")
+		}
+	}
+	for _, name := range sortedKeys(value) {
+		if property, ok := s.Properties[name]; ok {
+			validateSchema(property, value[name], pointer+"/"+escape(name), report)
+		} else if s.AdditionalProperties != nil {
+			validateSchema(s.AdditionalProperties, value[name], pointer+"/"+escape(name), report)
+		} else if s.NoAdditionalProperties {
+			report(pointer+"/"+escape(name), "is not allowed")
+		}
+	}
+}
+
+// matching returns the number of the schemas the value meets.
+func matching(schemas []*schema, value interface{}) int {
+	n := 0
+	for _, s := range schemas {
+		valid := true
+		validateSchema(s, value, "", func(string, string) { valid = false })
+		if valid {
+			n++
+		}
+	}
+	return n
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+var typeDescriptions = map[string]string{
+	"object":  "an object",
+	"array":   "an array",
+	"string":  "a string",
+	"number":  "a number",
+	"integer": "an integer",
+	"boolean": "a boolean",
+}
+
+func hasType(value interface{}, t string) bool {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && value == float64(int64(value)))
+	case bool:
+		return t == "boolean"
+	}
+	return false
+}
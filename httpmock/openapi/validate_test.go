@@ -0,0 +1,108 @@
+package openapi_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/openapi"
+
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func validate(spec *Spec, method, url, contentType, body string, headers ...string) []string {
+	request := httptest.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	var violations []string
+	for _, v := range spec.ValidateRequest(request) {
+		violations = append(violations, v.String())
+	}
+	return violations
+}
+
+func TestValidateRequest(t *testing.T) {
+	spec, err := LoadSpec("./testdata/petstore.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Empty(t, validate(spec, "GET", "/v1/pets?limit=10", "", ""))
+	assert.Empty(t, validate(spec, "GET", "/v1/pets/7", "", ""))
+	assert.Empty(t, validate(spec, "POST", "/v1/pets", "application/json", `{"name": "Rex", "tag": "dog"}`,
+		"X-Request-Id", "12345678"))
+
+	assert.Equal(t, []string{"query limit: must be an integer"},
+		validate(spec, "GET", "/v1/pets?limit=ten", "", ""))
+	assert.Equal(t, []string{"query limit: must be at most 100"},
+		validate(spec, "GET", "/v1/pets?limit=101", "", ""))
+	assert.Equal(t, []string{"path petId: must be an integer"},
+		validate(spec, "GET", "/v1/pets/rex", "", ""))
+	assert.Equal(t, []string{"method: PATCH is not an operation of /pets/{petId}"},
+		validate(spec, "PATCH", "/v1/pets/7", "", ""))
+	assert.Equal(t, []string{"path: /v1/owners matches no path of the spec"},
+		validate(spec, "GET", "/v1/owners", "", ""))
+	assert.Equal(t, []string{"path: /pets is not below /v1"},
+		validate(spec, "GET", "/pets", "", ""))
+	assert.Equal(t, []string{"path: /v1pets is not below /v1"},
+		validate(spec, "GET", "/v1pets", "", ""))
+
+	assert.Equal(t, []string{"header X-Request-Id: is required", "body: is required"},
+		validate(spec, "POST", "/v1/pets", "", ""))
+	assert.Equal(t, []string{
+		"header X-Request-Id: must be at least 8 character(s) long",
+		"body /name: is required",
+		"body /age: is not allowed",
+		"body /tag: must be one of [\"dog\",\"cat\"]",
+	}, validate(spec, "POST", "/v1/pets", "application/json", `{"age": 3, "tag": "fish"}`, "X-Request-Id", "1"))
+	assert.Equal(t, []string{`header X-Request-Id: must match "^[0-9]+$"`},
+		validate(spec, "POST", "/v1/pets", "application/json", `{"name": "Rex"}`, "X-Request-Id", "abcdefgh"))
+	assert.Equal(t, []string{"body /name: must not be null"},
+		validate(spec, "POST", "/v1/pets", "application/json", `{"name": null}`, "X-Request-Id", "12345678"))
+	assert.Equal(t, []string{`body: content type "text/plain" is not one of application/json`},
+		validate(spec, "POST", "/v1/pets", "text/plain", "Rex", "X-Request-Id", "12345678"))
+	violations := validate(spec, "POST", "/v1/pets", "application/json", `{"name": `, "X-Request-Id", "12345678")
+	if assert.Len(t, violations, 1) {
+		assert.True(t, strings.HasPrefix(violations[0], "body: is not valid JSON: "), violations[0])
+	}
+}
+
+func TestValidateRequestAgainstSpec(t *testing.T) {
+	spec, err := LoadSpec("./testdata/petstore.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	mock := Mockery(func() {
+		Endpoint("/v1/pets", func() {
+			Method("POST", func() {
+				RespondWithJson(201, map[string]interface{}{"id": 1, "name": "Rex"})
+				ValidateRequestAgainst(spec)
+			})
+		})
+	})
+
+	request := httptest.NewRequest("POST", "/v1/pets", strings.NewReader(`{"name": "Rex"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Request-Id", "12345678")
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, request)
+	assert.Equal(t, 201, w.Code)
+
+	request = httptest.NewRequest("POST", "/v1/pets", strings.NewReader(`{"name": 1}`))
+	request.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mock.ServeHTTP(w, request)
+	assert.Equal(t, 400, w.Code)
+	assert.JSONEq(t, `{"message": "the request is not valid", "violations": [
+		{"in": "header", "name": "X-Request-Id", "message": "is required"},
+		{"in": "body", "name": "/name", "message": "must be a string"}]}`, w.Body.String())
+
+	invalid := mock.InvalidRequests()
+	if assert.Len(t, invalid, 1) {
+		assert.Len(t, invalid[0].Violations, 2)
+	}
+}
@@ -0,0 +1,125 @@
+package httpmock
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyExchange is a request forwarded by a proxy along with the response it received.
+type ProxyExchange struct {
+	Request     *http.Request
+	RequestBody []byte
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// ProxyRecorder is passed every exchange forwarded by RecordingProxyTo.  Record may be called from many goroutines
+// at once.
+type ProxyRecorder interface {
+	Record(exchange *ProxyExchange)
+}
+
+// hopHeaders are the headers that apply to a single connection and must not be forwarded by a proxy.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ProxyTo forwards the request to the upstream server at baseURL and responds with the upstream's response.  The path
+// of the request is appended to the path of the baseURL.  If the upstream can't be reached 502 is returned.
+func ProxyTo(baseURL string) {
+	CurrentBuilder().ProxyTo(baseURL)
+}
+
+// ProxyTo forwards the request to the upstream server at baseURL and responds with the upstream's response.
+func (b *Builder) ProxyTo(baseURL string) {
+	b.proxyTo(baseURL, nil)
+}
+
+// RecordingProxyTo is like ProxyTo but each exchange with the upstream is passed to the recorder, see
+// wiremock.NewRecorder.
+func RecordingProxyTo(baseURL string, recorder ProxyRecorder) {
+	CurrentBuilder().RecordingProxyTo(baseURL, recorder)
+}
+
+// RecordingProxyTo is like ProxyTo but each exchange with the upstream is passed to the recorder.
+func (b *Builder) RecordingProxyTo(baseURL string, recorder ProxyRecorder) {
+	b.proxyTo(baseURL, recorder)
+}
+
+func (b *Builder) proxyTo(baseURL string, recorder ProxyRecorder) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		b.Errorf("invalid base URL for ProxyTo: %s", err.Error())
+		return
+	}
+	b.DecorateHandlerAfter(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		var requestBody []byte
+		if request.Body != nil {
+			requestBody, _ = ioutil.ReadAll(request.Body)
+			request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+		}
+		upstreamRequest, err := http.NewRequest(request.Method, upstreamURL(base, request.URL), bytes.NewReader(requestBody))
+		if err != nil {
+			log.Printf("ERROR while creating the proxy request: %+v", err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		copyHeader(upstreamRequest.Header, request.Header)
+		upstreamRequest.Host = base.Host
+		response, err := http.DefaultTransport.RoundTrip(upstreamRequest)
+		if err != nil {
+			log.Printf("ERROR while proxying to %s: %+v", upstreamRequest.URL, err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer response.Body.Close()
+		copyHeader(w.Header(), response.Header)
+		w.WriteHeader(response.StatusCode)
+		if recorder == nil {
+			io.Copy(w, response.Body)
+			return
+		}
+		var responseBody bytes.Buffer
+		io.Copy(w, io.TeeReader(response.Body, &responseBody))
+		recorder.Record(&ProxyExchange{
+			Request:     request,
+			RequestBody: requestBody,
+			Status:      response.StatusCode,
+			Header:      response.Header,
+			Body:        responseBody.Bytes(),
+		})
+	}))
+}
+
+// upstreamURL returns the URL of the request to the upstream server.
+func upstreamURL(base *url.URL, requestURL *url.URL) string {
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + requestURL.Path
+	u.RawPath = ""
+	u.RawQuery = requestURL.RawQuery
+	return u.String()
+}
+
+// copyHeader copies the headers, except for the hop by hop headers.
+func copyHeader(dst, src http.Header) {
+	for name, values := range src {
+		dst[name] = append([]string(nil), values...)
+	}
+	for _, name := range hopHeaders {
+		dst.Del(name)
+	}
+}
@@ -0,0 +1,91 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxyTo(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		w.Header().Set("X-Upstream-Path", request.URL.RequestURI())
+		w.Header().Set("X-Upstream-Trace", request.Header.Get("X-Trace"))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(request.Method + " " + string(body)))
+	}))
+	defer upstream.Close()
+
+	mock := Mockery(func() {
+		EndpointPattern("/orders/.*", func() {
+			ProxyTo(upstream.URL + "/api")
+		})
+	})
+
+	request := httptest.NewRequest("POST", "http://localhost/orders/1?expand=true", strings.NewReader("order"))
+	request.Header.Set("X-Trace", "abc")
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "POST order", w.Body.String())
+	assert.Equal(t, "/api/orders/1?expand=true", w.Header().Get("X-Upstream-Path"))
+	assert.Equal(t, "abc", w.Header().Get("X-Upstream-Trace"))
+	assert.Equal(t, []byte("order"), mock.Requests()[0].Body)
+}
+
+func TestProxyToUnreachable(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	mock := Mockery(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				ProxyTo(upstream.URL)
+			})
+		})
+	})
+
+	status, _ := serve(mock, "GET", "http://localhost/orders")
+	assert.Equal(t, http.StatusBadGateway, status)
+}
+
+type exchangeRecorder struct {
+	exchanges []*ProxyExchange
+}
+
+func (r *exchangeRecorder) Record(exchange *ProxyExchange) {
+	r.exchanges = append(r.exchanges, exchange)
+}
+
+func TestRecordingProxyTo(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("upstream"))
+	}))
+	defer upstream.Close()
+
+	recorder := &exchangeRecorder{}
+	mock := Mockery(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				RecordingProxyTo(upstream.URL, recorder)
+			})
+		})
+	})
+
+	status, body := serve(mock, "GET", "http://localhost/orders")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "upstream", body)
+	if assert.Len(t, recorder.exchanges, 1) {
+		assert.Equal(t, "/orders", recorder.exchanges[0].Request.URL.Path)
+		assert.Equal(t, 200, recorder.exchanges[0].Status)
+		assert.Equal(t, "text/plain", recorder.exchanges[0].Header.Get("Content-Type"))
+		assert.Equal(t, []byte("upstream"), recorder.exchanges[0].Body)
+	}
+}
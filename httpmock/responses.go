@@ -11,6 +11,12 @@ import (
 // WriteStatusAndBody writes the given status with the given body.  It is expected that any headers needed by the
 // response have been added as this will being the sending of the response.
 func WriteStatusAndBody(status int, body interface{}) {
+	CurrentBuilder().WriteStatusAndBody(status, body)
+}
+
+// WriteStatusAndBody writes the given status with the given body.  It is expected that any headers needed by the
+// response have been added as this will being the sending of the response.
+func (b *Builder) WriteStatusAndBody(status int, body interface{}) {
 	var bodyProvider func() io.ReadCloser
 	var contentType string
 	switch bdy := body.(type) {
@@ -27,17 +33,24 @@ func WriteStatusAndBody(status int, body interface{}) {
 	default:
 		bdyBytes, err := json.Marshal(bdy)
 		if err != nil {
-			panic("unable to marshal Body to json!")
+			b.Errorf("unable to marshal body to JSON: %s", err.Error())
+			return
 		}
 		bodyProvider = func() io.ReadCloser { return ioutil.NopCloser(bytes.NewBuffer(bdyBytes)) }
-		Header("Content-Type", "application/json")
+		b.Header("Content-Type", "application/json")
 	}
-	DecorateHandler(NoopHandler, http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
-		readCloser := bodyProvider()
+	b.writeStatusAndBody(status, func(*http.Request) io.ReadCloser { return bodyProvider() }, contentType)
+}
+
+// writeStatusAndBody decorates the current handler so that it writes the status and the body produced for the
+// request.  If the bodyProvider returns nil a 500 is written instead.
+func (b *Builder) writeStatusAndBody(status int, bodyProvider func(*http.Request) io.ReadCloser, contentType string) {
+	b.DecorateHandler(NoopHandler, http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		readCloser := bodyProvider(request)
 		if readCloser != nil {
 			defer readCloser.Close()
 			if contentType != "" {
-				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Type", contentType)
 			}
 			w.WriteHeader(status)
 			io.Copy(w, readCloser)
@@ -49,20 +62,40 @@ func WriteStatusAndBody(status int, body interface{}) {
 
 // Created is a shortcut for returning Created (201) http response with no body.
 func Created() {
-	Respond(201)
+	CurrentBuilder().Created()
+}
+
+// Created is a shortcut for returning Created (201) http response with no body.
+func (b *Builder) Created() {
+	b.Respond(201)
 }
 
 // RespondWithBadRequest is a shortcut for returning Bad Request (400) with the given body.
 func RespondWithBadRequest(body interface{}) {
-	WriteStatusAndBody(400, body)
+	CurrentBuilder().RespondWithBadRequest(body)
+}
+
+// RespondWithBadRequest is a shortcut for returning Bad Request (400) with the given body.
+func (b *Builder) RespondWithBadRequest(body interface{}) {
+	b.WriteStatusAndBody(400, body)
 }
 
 // RespondWithInternalServerError is a shortcut for returning Internal Server Error (500) with the given body.
 func RespondWithInternalServerError(body interface{}) {
-	WriteStatusAndBody(500, body)
+	CurrentBuilder().RespondWithInternalServerError(body)
+}
+
+// RespondWithInternalServerError is a shortcut for returning Internal Server Error (500) with the given body.
+func (b *Builder) RespondWithInternalServerError(body interface{}) {
+	b.WriteStatusAndBody(500, body)
 }
 
 // NotFound is a shortcut for returning Not Found (404) with no body.
 func NotFound() {
-	Respond(404)
+	CurrentBuilder().NotFound()
+}
+
+// NotFound is a shortcut for returning Not Found (404) with no body.
+func (b *Builder) NotFound() {
+	b.Respond(404)
 }
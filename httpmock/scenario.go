@@ -0,0 +1,219 @@
+package httpmock
+
+import (
+	"fmt"
+	"github.com/bluesoftdev/go-http-matchers/extractor"
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ScenarioStarted is the state every scenario is in when the mockery is created or its scenarios are reset.
+const ScenarioStarted = "Started"
+
+// scenario is a named state machine shared by the endpoints of a mockery.
+type scenario struct {
+	lock  sync.RWMutex
+	name  string
+	state string
+
+	// selectLock is held while an endpoint that transitions the scenario is checked and selected, so that no other
+	// request can select an endpoint for the state it leaves.
+	selectLock sync.Mutex
+}
+
+// scenarioTransition is a TransitionTo made when its endpoint is selected for a request with the method, if it is
+// set.
+type scenarioTransition struct {
+	scenario *scenario
+	method   string
+	state    string
+}
+
+func (s *scenario) State() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state
+}
+
+func (s *scenario) setState(state string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.state = state
+}
+
+// scenario returns the named scenario, creating it in the ScenarioStarted state if it does not exist yet.
+func (m *mockery) scenario(name string) *scenario {
+	m.scenarioLock.Lock()
+	defer m.scenarioLock.Unlock()
+	if m.scenarios == nil {
+		m.scenarios = make(map[string]*scenario)
+	}
+	s, ok := m.scenarios[name]
+	if !ok {
+		s = &scenario{name: name, state: ScenarioStarted}
+		m.scenarios[name] = s
+	}
+	return s
+}
+
+func (m *mockery) ResetScenarios() {
+	m.scenarioLock.Lock()
+	defer m.scenarioLock.Unlock()
+	for _, s := range m.scenarios {
+		s.setState(ScenarioStarted)
+	}
+	for _, ss := range m.sequences {
+		atomic.StoreInt64(&ss.next, 0)
+	}
+}
+
+// addSequence registers the Sequence or RoundRobin so that ResetScenarios returns it to its first Step.
+func (m *mockery) addSequence(ss *sequenceSet) {
+	m.scenarioLock.Lock()
+	defer m.scenarioLock.Unlock()
+	m.sequences = append(m.sequences, ss)
+}
+
+// Scenario establishes the named scenario for the InState and TransitionTo elements used within the configFunc.  A
+// scenario is a state machine, its state starts as ScenarioStarted and is changed by responses that use TransitionTo.
+// Endpoints defined within InState only match while the scenario is in that state, e.g.
+//
+//    Scenario("order", func() {
+//      InState(ScenarioStarted, func() {
+//        EndpointPattern("^/order/1$", func() {
+//          RespondWithString(200, "PENDING")
+//        })
+//      })
+//      InState("SHIPPED", func() {
+//        EndpointPattern("^/order/1$", func() {
+//          RespondWithString(200, "SHIPPED")
+//        })
+//      })
+//      EndpointPattern("^/ship$", func() {
+//        TransitionTo("SHIPPED")
+//        Respond(204)
+//      })
+//    })
+//
+func Scenario(name string, configFunc func()) {
+	CurrentBuilder().Scenario(name, configFunc)
+}
+
+// Scenario establishes the named scenario for the InState and TransitionTo elements used within the configFunc.
+func (b *Builder) Scenario(name string, configFunc func()) {
+	outerScenario := b.scenario
+	b.scenario = b.mockery.scenario(name)
+	configFunc()
+	b.scenario = outerScenario
+}
+
+// InState restricts the endpoints defined within the configFunc so that they are only selected while the current
+// scenario is in the given state.  It must be used within a Scenario and around endpoint definitions.
+func InState(state string, configFunc func()) {
+	CurrentBuilder().InState(state, configFunc)
+}
+
+// InState restricts the endpoints defined within the configFunc so that they are only selected while the current
+// scenario is in the given state.  It must be used within a Scenario and around endpoint definitions.
+func (b *Builder) InState(state string, configFunc func()) {
+	if b.scenario == nil {
+		b.Errorf("InState must be used within a Scenario")
+		return
+	}
+	outerCondition := b.condition
+	b.condition = b.and(scenarioInState(b.scenario, state))
+	configFunc()
+	b.condition = outerCondition
+}
+
+// TransitionTo changes the state of the current scenario.  It must be used within a Scenario.  Used directly within an
+// endpoint, or a Method of one, the state changes when the endpoint is selected: the states it requires are checked
+// and the transition made together, so two requests can't both be served the response for the state it leaves.
+// Used within the responses of a Switch, When, Weighted or Sequence, the state changes once the response has been
+// served.
+func TransitionTo(state string) {
+	CurrentBuilder().TransitionTo(state)
+}
+
+// TransitionTo changes the state of the current scenario when the endpoint is selected or, within a conditional
+// response, once the response has been served.  It must be used within a Scenario.
+func (b *Builder) TransitionTo(state string) {
+	if b.scenario == nil {
+		b.Errorf("TransitionTo must be used within a Scenario")
+		return
+	}
+	s := b.scenario
+	if b.transitions != nil {
+		*b.transitions = append(*b.transitions, scenarioTransition{s, b.method, state})
+		return
+	}
+	b.DecorateHandlerAfter(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		s.setState(state)
+	}))
+}
+
+// selects returns whether the handler is selected for the request.  If it transitions scenarios, the predicate is
+// checked and the transitions made while holding the selectLock of each, taken in the order of their names.
+func (h *mockeryHandler) selects(request *http.Request) bool {
+	if len(h.transitions) == 0 {
+		return h.predicate.Accept(request)
+	}
+	scenarios := make([]*scenario, 0, len(h.transitions))
+	for _, t := range h.transitions {
+		if t.method == "" || t.method == request.Method {
+			scenarios = append(scenarios, t.scenario)
+		}
+	}
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].name < scenarios[j].name })
+	for i, s := range scenarios {
+		if i == 0 || s != scenarios[i-1] {
+			s.selectLock.Lock()
+			defer s.selectLock.Unlock()
+		}
+	}
+	if !h.predicate.Accept(request) {
+		return false
+	}
+	for _, t := range h.transitions {
+		if t.method == "" || t.method == request.Method {
+			t.scenario.setState(t.state)
+		}
+	}
+	return true
+}
+
+// ScenarioState returns an extractor that returns the current state of the named scenario, it may be used as the key
+// supplier of a Switch.
+func ScenarioState(name string) extractor.Extractor {
+	return CurrentBuilder().ScenarioState(name)
+}
+
+// ScenarioState returns an extractor that returns the current state of the named scenario, it may be used as the key
+// supplier of a Switch.
+func (b *Builder) ScenarioState(name string) extractor.Extractor {
+	s := b.mockery.scenario(name)
+	return extractor.ExtractorFunc(func(interface{}) interface{} {
+		return s.State()
+	})
+}
+
+// ScenarioInState returns a predicate that is true while the named scenario is in the given state, it may be used
+// with When or EndpointForCondition.
+func ScenarioInState(name, state string) predicate.Predicate {
+	return CurrentBuilder().ScenarioInState(name, state)
+}
+
+// ScenarioInState returns a predicate that is true while the named scenario is in the given state, it may be used
+// with When or EndpointForCondition.
+func (b *Builder) ScenarioInState(name, state string) predicate.Predicate {
+	return scenarioInState(b.mockery.scenario(name), state)
+}
+
+func scenarioInState(s *scenario, state string) predicate.Predicate {
+	return Describe(fmt.Sprintf("scenario %s is in state %s", s.name, state), predicate.PredicateFunc(func(interface{}) bool {
+		return s.State() == state
+	}))
+}
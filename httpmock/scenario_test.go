@@ -0,0 +1,151 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/go-http-matchers/predicate"
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func serve(mock Mock, method, url string) (int, string) {
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest(method, url, nil))
+	return mockWriter.Code, mockWriter.Body.String()
+}
+
+func TestScenarioInState(t *testing.T) {
+	mock := Mockery(func() {
+		Scenario("order", func() {
+			InState(ScenarioStarted, func() {
+				Endpoint("/order/1", func() {
+					Method("GET", func() {
+						RespondWithString(200, "PENDING")
+					})
+				})
+			})
+			InState("SHIPPED", func() {
+				Endpoint("/order/1", func() {
+					Method("GET", func() {
+						RespondWithString(200, "SHIPPED")
+					})
+				})
+			})
+			EndpointForCondition(And(PathEquals("/ship"), MethodIs("POST")), func() {
+				TransitionTo("SHIPPED")
+				Respond(204)
+			})
+		})
+	})
+
+	code, body := serve(mock, "GET", "/order/1")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "PENDING", body)
+
+	code, _ = serve(mock, "POST", "/ship")
+	assert.Equal(t, 204, code)
+
+	code, body = serve(mock, "GET", "/order/1")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "SHIPPED", body)
+
+	mock.ResetScenarios()
+	_, body = serve(mock, "GET", "/order/1")
+	assert.Equal(t, "PENDING", body)
+}
+
+func TestScenarioStateSwitch(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/light", func() {
+			b.Method("GET", func() {
+				b.Switch(b.ScenarioState("light"), func() {
+					b.Case(StringEquals("ON"), func() {
+						b.RespondWithString(200, "on")
+					})
+					b.Default(func() {
+						b.RespondWithString(200, "off")
+					})
+				})
+			})
+			b.Method("POST", func() {
+				b.Scenario("light", func() {
+					b.When(b.ScenarioInState("light", "ON"), func() {
+						b.TransitionTo(ScenarioStarted)
+					}, func() {
+						b.TransitionTo("ON")
+					})
+					b.Respond(204)
+				})
+			})
+		})
+	})
+
+	_, body := serve(mock, "GET", "/light")
+	assert.Equal(t, "off", body)
+	serve(mock, "POST", "/light")
+	_, body = serve(mock, "GET", "/light")
+	assert.Equal(t, "on", body)
+	serve(mock, "POST", "/light")
+	_, body = serve(mock, "GET", "/light")
+	assert.Equal(t, "off", body)
+}
+
+func TestScenarioTransitionIsAtomic(t *testing.T) {
+	mock := Mockery(func() {
+		Scenario("ticket", func() {
+			InState(ScenarioStarted, func() {
+				Endpoint("/ticket", func() {
+					Method("POST", func() {
+						TransitionTo("TAKEN")
+						FixedDelay("20ms")
+						RespondWithString(200, "first")
+					})
+				})
+			})
+			InState("TAKEN", func() {
+				Endpoint("/ticket", func() {
+					Method("POST", func() {
+						RespondWithString(200, "later")
+					})
+				})
+			})
+		})
+	})
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	bodies := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, body := serve(mock, "POST", "/ticket")
+			lock.Lock()
+			bodies[body]++
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[string]int{"first": 1, "later": 19}, bodies)
+
+	mock.ResetScenarios()
+	code, _ := serve(mock, "GET", "/ticket")
+	assert.Equal(t, 404, code)
+	_, body := serve(mock, "POST", "/ticket")
+	assert.Equal(t, "first", body, "a request with another method must not make the transition")
+}
+
+func TestScenarioMisuse(t *testing.T) {
+	assert.Panics(t, func() {
+		New(func(b *Builder) {
+			b.InState("SHIPPED", func() {})
+		})
+	})
+	assert.Panics(t, func() {
+		New(func(b *Builder) {
+			b.TransitionTo("SHIPPED")
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package httpmock
+
+import (
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+)
+
+type weightedSet struct {
+	weights  []int
+	handlers []http.Handler
+	total    int
+}
+
+// Weighted can be used within a Method's config function to choose one of many possible responses at random.  Each
+// Weight is chosen with a probability proportional to its weight, e.g. to fail 2% of the requests:
+//
+//    Weighted(func() {
+//      Weight(98, func() {
+//        RespondWithFile(200, "./ok.json")
+//      })
+//      Weight(2, func() {
+//        RespondWithInternalServerError("unavailable")
+//      })
+//    })
+//
+func Weighted(weights func()) {
+	CurrentBuilder().Weighted(weights)
+}
+
+// Weighted can be used within a Method's config function to choose one of many possible responses at random.
+func (b *Builder) Weighted(weights func()) {
+	ws := &weightedSet{}
+	outerWeighted := b.weighted
+	b.weighted = ws
+	weights()
+	b.weighted = outerWeighted
+	if ws.total == 0 {
+		b.Errorf("Weighted must contain a Weight")
+		return
+	}
+	b.handler = ws
+}
+
+// Weight is used within Weighted to define a response that is chosen with a probability proportional to the weight.
+func Weight(weight int, responseBuilder func()) {
+	CurrentBuilder().Weight(weight, responseBuilder)
+}
+
+// Weight is used within Weighted to define a response that is chosen with a probability proportional to the weight.
+func (b *Builder) Weight(weight int, responseBuilder func()) {
+	if b.weighted == nil {
+		b.Errorf("Weight must be used within Weighted")
+		return
+	}
+	if weight <= 0 {
+		b.Errorf("weight must be greater than 0 but is %d", weight)
+		return
+	}
+	b.weighted.weights = append(b.weighted.weights, weight)
+	b.weighted.handlers = append(b.weighted.handlers, b.branch(responseBuilder))
+	b.weighted.total += weight
+}
+
+func (ws *weightedSet) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	n := rand.Intn(ws.total)
+	for i, weight := range ws.weights {
+		if n < weight {
+			ws.handlers[i].ServeHTTP(w, request)
+			return
+		}
+		n -= weight
+	}
+}
+
+type sequenceSet struct {
+	handlers []http.Handler
+	sticky   bool
+	next     int64
+}
+
+// Sequence can be used within a Method's config function to respond with each Step in turn, once the last Step is
+// reached it is used for every request that follows.  ResetScenarios returns it to the first Step.  e.g. to fail twice
+// before succeeding:
+//
+//    Sequence(func() {
+//      Step(func() {
+//        RespondWithInternalServerError("unavailable")
+//      })
+//      Step(func() {
+//        RespondWithInternalServerError("unavailable")
+//      })
+//      Step(func() {
+//        RespondWithFile(200, "./ok.json")
+//      })
+//    })
+//
+func Sequence(steps func()) {
+	CurrentBuilder().Sequence(steps)
+}
+
+// Sequence can be used within a Method's config function to respond with each Step in turn, sticking on the last.
+func (b *Builder) Sequence(steps func()) {
+	b.sequence("Sequence", true, steps)
+}
+
+// RoundRobin is like Sequence but once the last Step has been used it starts again from the first.
+func RoundRobin(steps func()) {
+	CurrentBuilder().RoundRobin(steps)
+}
+
+// RoundRobin is like Sequence but once the last Step has been used it starts again from the first.
+func (b *Builder) RoundRobin(steps func()) {
+	b.sequence("RoundRobin", false, steps)
+}
+
+func (b *Builder) sequence(element string, sticky bool, steps func()) {
+	ss := &sequenceSet{sticky: sticky}
+	outerSequence := b.steps
+	b.steps = ss
+	steps()
+	b.steps = outerSequence
+	if len(ss.handlers) == 0 {
+		b.Errorf("%s must contain a Step", element)
+		return
+	}
+	b.mockery.addSequence(ss)
+	b.handler = ss
+}
+
+// Step is used within Sequence or RoundRobin to define the next response.
+func Step(responseBuilder func()) {
+	CurrentBuilder().Step(responseBuilder)
+}
+
+// Step is used within Sequence or RoundRobin to define the next response.
+func (b *Builder) Step(responseBuilder func()) {
+	if b.steps == nil {
+		b.Errorf("Step must be used within a Sequence or RoundRobin")
+		return
+	}
+	b.steps.handlers = append(b.steps.handlers, b.branch(responseBuilder))
+}
+
+func (ss *sequenceSet) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	n := int(atomic.AddInt64(&ss.next, 1) - 1)
+	if ss.sticky {
+		n = minInt(n, len(ss.handlers)-1)
+	} else {
+		n %= len(ss.handlers)
+	}
+	ss.handlers[n].ServeHTTP(w, request)
+}
+
+// branch returns the handler built by the responseBuilder on top of the current handler, which is left unchanged.
+func (b *Builder) branch(responseBuilder func()) http.Handler {
+	outerHandler := b.handler
+	outerTransitions := b.transitions
+	b.transitions = nil
+	responseBuilder()
+	b.transitions = outerTransitions
+	handler := b.handler
+	b.handler = outerHandler
+	return handler
+}
@@ -0,0 +1,112 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/flaky", func() {
+			Method("GET", func() {
+				Weighted(func() {
+					Weight(90, func() {
+						RespondWithString(200, "ok")
+					})
+					Weight(10, func() {
+						RespondWithString(503, "unavailable")
+					})
+				})
+			})
+		})
+	})
+
+	counts := make(map[int]int)
+	for i := 0; i < 2000; i++ {
+		code, _ := serve(mock, "GET", "/flaky")
+		counts[code]++
+	}
+	assert.Equal(t, 2000, counts[200]+counts[503])
+	assert.InDelta(t, 200, counts[503], 60, "%d of 2000 were 503", counts[503])
+}
+
+func TestSequence(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/retry", func() {
+			b.Method("GET", func() {
+				b.Sequence(func() {
+					b.Step(func() {
+						b.RespondWithString(503, "first")
+					})
+					b.Step(func() {
+						b.RespondWithString(503, "second")
+					})
+					b.Step(func() {
+						b.RespondWithString(200, "ok")
+					})
+				})
+			})
+		})
+	})
+
+	var bodies []string
+	for i := 0; i < 5; i++ {
+		_, body := serve(mock, "GET", "/retry")
+		bodies = append(bodies, body)
+	}
+	assert.Equal(t, []string{"first", "second", "ok", "ok", "ok"}, bodies)
+
+	mock.ResetScenarios()
+	code, body := serve(mock, "GET", "/retry")
+	assert.Equal(t, 503, code)
+	assert.Equal(t, "first", body)
+}
+
+func TestRoundRobin(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/backend", func() {
+			Method("GET", func() {
+				RoundRobin(func() {
+					Step(func() {
+						RespondWithString(200, "a")
+					})
+					Step(func() {
+						RespondWithString(200, "b")
+					})
+				})
+			})
+		})
+	})
+
+	var bodies []string
+	for i := 0; i < 5; i++ {
+		_, body := serve(mock, "GET", "/backend")
+		bodies = append(bodies, body)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b", "a"}, bodies)
+}
+
+func TestSelectionConfigErrors(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.Weighted(func() {
+			b.Weight(0, func() {
+				b.Respond(200)
+			})
+		})
+		b.Weight(1, func() {})
+		b.Sequence(func() {})
+		b.RoundRobin(func() {})
+		b.Step(func() {})
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 6) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "weight must be greater than 0 but is 0", errs[0].Message)
+		assert.Equal(t, "Weighted must contain a Weight", errs[1].Message)
+		assert.Equal(t, "Weight must be used within Weighted", errs[2].Message)
+		assert.Equal(t, "Sequence must contain a Step", errs[3].Message)
+		assert.Equal(t, "RoundRobin must contain a Step", errs[4].Message)
+		assert.Equal(t, "Step must be used within a Sequence or RoundRobin", errs[5].Message)
+	}
+}
@@ -0,0 +1,251 @@
+package httpmock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"github.com/bluesoftdev/go-http-matchers/extractor"
+	"github.com/bluesoftdev/mockery/httpmock/internal/uuid"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data passed to the templates used by RespondWithTemplate and HeaderTemplate.
+type TemplateData struct {
+	Request *TemplateRequest
+}
+
+// TemplateRequest exposes the request being served to response templates, e.g. {{.Request.Method}} or
+// {{.Request.QueryParam "id"}}.
+type TemplateRequest struct {
+	Method       string
+	URL          string
+	Path         string
+	PathSegments []string
+	Query        url.Values
+	Headers      http.Header
+	Cookies      map[string]string
+	Body         string
+
+	request *http.Request
+}
+
+func newTemplateRequest(request *http.Request) *TemplateRequest {
+	var body []byte
+	if request.Body != nil {
+		body, _ = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	cookies := make(map[string]string)
+	for _, cookie := range request.Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	return &TemplateRequest{
+		Method:       request.Method,
+		URL:          request.URL.RequestURI(),
+		Path:         request.URL.Path,
+		PathSegments: strings.Split(strings.TrimPrefix(request.URL.Path, "/"), "/"),
+		Query:        request.URL.Query(),
+		Headers:      request.Header,
+		Cookies:      cookies,
+		Body:         string(body),
+		request:      request,
+	}
+}
+
+// PathSegment returns the path segment at the index given, starting at 0, or "" if there is no such segment.  A
+// negative index counts from the end of the path.
+func (tr *TemplateRequest) PathSegment(index int) string {
+	if index < 0 {
+		index += len(tr.PathSegments)
+	}
+	if index < 0 || index >= len(tr.PathSegments) {
+		return ""
+	}
+	return tr.PathSegments[index]
+}
+
+// QueryParam returns the first value of the named query parameter.
+func (tr *TemplateRequest) QueryParam(name string) string {
+	return tr.Query.Get(name)
+}
+
+// Header returns the first value of the named request header.
+func (tr *TemplateRequest) Header(name string) string {
+	return extractor.ExtractHeader(name).Extract(tr.request).(string)
+}
+
+// Cookie returns the value of the named cookie.
+func (tr *TemplateRequest) Cookie(name string) string {
+	return tr.Cookies[name]
+}
+
+// XPath returns the string value of the XPath expression evaluated against the XML request body.
+func (tr *TemplateRequest) XPath(xpath string) string {
+	return extractor.ExtractXPathString(xpath).Extract(tr.bodyRequest()).(string)
+}
+
+// JSONPath returns the value of the JSONPath expression, as described by ExtractJSONPath, evaluated against the JSON
+// request body.  If there is no such value "" is returned.
+func (tr *TemplateRequest) JSONPath(path string) string {
+	value := ExtractJSONPath(path).Extract(tr.bodyRequest())
+	if value == nil {
+		return ""
+	}
+	return value.(string)
+}
+
+// bodyRequest returns a request whose body may be consumed by an extractor.
+func (tr *TemplateRequest) bodyRequest() *http.Request {
+	r := *tr.request
+	r.Body = ioutil.NopCloser(strings.NewReader(tr.Body))
+	return &r
+}
+
+const (
+	alphanumericChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	alphabeticChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	numericChars      = "0123456789"
+	hexadecimalChars  = "0123456789abcdef"
+)
+
+func randomString(length int, chars string) string {
+	result := make([]byte, length)
+	max := big.NewInt(int64(len(chars)))
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err.Error())
+		}
+		result[i] = chars[n.Int64()]
+	}
+	return string(result)
+}
+
+// templateFuncs are the helper functions available to response templates:
+//
+//    now [layout]               the current time formatted with the layout given, time.RFC3339 by default.
+//    randomId length            a random alphanumeric string.
+//    randomValue length kind    a random string, kind is one of ALPHANUMERIC, ALPHABETIC, NUMERIC or HEXADECIMAL.
+//    uuid                       a random (version 4) UUID.
+//
+var templateFuncs = template.FuncMap{
+	"now": func(layout ...string) string {
+		if len(layout) > 0 {
+			return time.Now().Format(layout[0])
+		}
+		return time.Now().Format(time.RFC3339)
+	},
+	"randomId": func(length int) string {
+		return randomString(length, alphanumericChars)
+	},
+	"randomValue": func(length int, kind string) (string, error) {
+		switch strings.ToUpper(kind) {
+		case "ALPHANUMERIC":
+			return randomString(length, alphanumericChars), nil
+		case "ALPHABETIC":
+			return randomString(length, alphabeticChars), nil
+		case "NUMERIC":
+			return randomString(length, numericChars), nil
+		case "HEXADECIMAL":
+			return randomString(length, hexadecimalChars), nil
+		case "UUID":
+			return uuid.New(), nil
+		}
+		return "", fmt.Errorf("unknown random value kind %q", kind)
+	},
+	"uuid": uuid.New,
+}
+
+// parseTemplate parses the template, recording an error and returning nil if it can't be parsed.
+func (b *Builder) parseTemplate(name, text string) *template.Template {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		b.Errorf("invalid template %s: %s", name, err.Error())
+		return nil
+	}
+	return tmpl
+}
+
+func executeTemplate(tmpl *template.Template, request *http.Request) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, &TemplateData{Request: newTemplateRequest(request)})
+	return buf.Bytes(), err
+}
+
+// RespondWithTemplate responds with the status code given and a body produced by executing the text/template with
+// the request data.  The template is passed a TemplateData, e.g.
+//
+//    RespondWithTemplate(200, `{"id": "{{.Request.PathSegment 1}}", "trace": "{{.Request.Header "X-Trace"}}"}`)
+//
+// In addition to the text/template builtins, the functions now, randomId, randomValue and uuid are available.
+func RespondWithTemplate(status int, tmpl string) {
+	CurrentBuilder().RespondWithTemplate(status, tmpl)
+}
+
+// RespondWithTemplate responds with the status code given and a body produced by executing the text/template with
+// the request data.
+func (b *Builder) RespondWithTemplate(status int, tmpl string) {
+	if t := b.parseTemplate("body", tmpl); t != nil {
+		b.respondWithTemplate(status, t)
+	}
+}
+
+// RespondWithTemplateFile is like RespondWithTemplate but the template is read from the file given.
+func RespondWithTemplateFile(status int, fileName string) {
+	CurrentBuilder().RespondWithTemplateFile(status, fileName)
+}
+
+// RespondWithTemplateFile is like RespondWithTemplate but the template is read from the file given.
+func (b *Builder) RespondWithTemplateFile(status int, fileName string) {
+	text, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		b.Errorf("reading template file: %s", err.Error())
+		return
+	}
+	if t := b.parseTemplate(fileName, string(text)); t != nil {
+		b.respondWithTemplate(status, t)
+	}
+}
+
+func (b *Builder) respondWithTemplate(status int, tmpl *template.Template) {
+	b.writeStatusAndBody(status, func(request *http.Request) io.ReadCloser {
+		body, err := executeTemplate(tmpl, request)
+		if err != nil {
+			log.Printf("ERROR while executing template %s: %+v", tmpl.Name(), err)
+			return nil
+		}
+		return ioutil.NopCloser(bytes.NewReader(body))
+	}, "")
+}
+
+// HeaderTemplate adds a header to the response whose value is produced by executing the text/template with the
+// request data, see RespondWithTemplate.
+func HeaderTemplate(name, tmpl string) {
+	CurrentBuilder().HeaderTemplate(name, tmpl)
+}
+
+// HeaderTemplate adds a header to the response whose value is produced by executing the text/template with the
+// request data.
+func (b *Builder) HeaderTemplate(name, tmpl string) {
+	t := b.parseTemplate(name, tmpl)
+	if t == nil {
+		return
+	}
+	b.DecorateHandlerBefore(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		value, err := executeTemplate(t, request)
+		if err != nil {
+			log.Printf("ERROR while executing template for header %s: %+v", name, err)
+			return
+		}
+		w.Header().Add(name, string(value))
+	}))
+}
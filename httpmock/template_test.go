@@ -0,0 +1,73 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRespondWithTemplate(t *testing.T) {
+	mock := Mockery(func() {
+		EndpointPattern("^/orders/.+", func() {
+			HeaderTemplate("X-Order-Id", "{{.Request.PathSegment 1}}")
+			Header("Content-Type", "application/json")
+			RespondWithTemplate(200, `{"id": "{{.Request.PathSegment 1}}", "method": "{{.Request.Method}}", `+
+				`"q": "{{.Request.QueryParam "q"}}", "trace": "{{.Request.Header "X-Trace"}}", `+
+				`"session": "{{.Request.Cookie "session"}}", "name": "{{.Request.JSONPath "$.customer.name"}}", `+
+				`"first": "{{.Request.JSONPath "$.items[0]"}}", "request": "{{randomId 12}}", "uuid": "{{uuid}}", `+
+				`"now": "{{now "2006"}}"}`)
+		})
+	})
+
+	request := httptest.NewRequest("PUT", "/orders/1234?q=foo",
+		strings.NewReader(`{"customer": {"name": "joe"}, "items": ["apple", "pear"]}`))
+	request.Header.Set("X-Trace", "abc")
+	request.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, request)
+
+	assert.Equal(t, 200, mockWriter.Code)
+	assert.Equal(t, "1234", mockWriter.Header().Get("X-Order-Id"))
+	assert.Regexp(t, `^\{"id": "1234", "method": "PUT", "q": "foo", "trace": "abc", "session": "s1", `+
+		`"name": "joe", "first": "apple", "request": "[A-Za-z0-9]{12}", `+
+		`"uuid": "[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}", "now": "[0-9]{4}"\}$`,
+		mockWriter.Body.String())
+}
+
+func TestRespondWithTemplateFile(t *testing.T) {
+	mock := Mockery(func() {
+		EndpointPattern("^/orders/.+", func() {
+			RespondWithTemplateFile(201, "testdata/order.tmpl")
+		})
+	})
+
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest("POST", "/orders/42", strings.NewReader("<order><item>pen</item></order>")))
+
+	assert.Equal(t, 201, mockWriter.Code)
+	assert.Equal(t, "<order id=\"42\">pen</order>\n", mockWriter.Body.String())
+}
+
+func TestRespondWithTemplateExecutionError(t *testing.T) {
+	mock := Mockery(func() {
+		EndpointPattern("^/orders/.+", func() {
+			RespondWithTemplate(200, `{{randomValue 3 "BOGUS"}}`)
+		})
+	})
+
+	mockWriter := httptest.NewRecorder()
+	mock.ServeHTTP(mockWriter, httptest.NewRequest("GET", "/orders/42", nil))
+	assert.Equal(t, 500, mockWriter.Code)
+}
+
+func TestRespondWithTemplateParseError(t *testing.T) {
+	assert.Panics(t, func() {
+		New(func(b *Builder) {
+			b.RespondWithTemplate(200, "{{.Request.Method")
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package httpmock
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+)
+
+// TestingT is the part of *testing.T that StartServer uses.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// TestServer is a mock served by an httptest.Server for the life of a test.  The base URL of the mock is URL and its
+// request journal is available through the methods of the Mock.
+type TestServer struct {
+	*httptest.Server
+	Mock
+}
+
+// StartServer builds a mockery with the configFunc, as Mockery does, and serves it with an httptest.Server until the
+// test completes.  The test fails straight away if the configuration has errors.  When the test completes the server
+// is closed and the test fails if any request reached the mock that no endpoint matched, if ValidateRequestAgainst
+// found a request to be invalid or if an expectation declared with Expect was not met, e.g.
+//
+//    func TestOrders(t *testing.T) {
+//      server := httpmock.StartServer(t, func() {
+//        httpmock.Endpoint("/orders", func() {
+//          httpmock.Method("GET", func() {
+//            httpmock.RespondWithFile(200, "./testdata/orders.json")
+//          })
+//        })
+//      })
+//      client := NewOrdersClient(server.URL)
+//      ...
+//    }
+//
+func StartServer(t TestingT, configFunc func()) *TestServer {
+	t.Helper()
+	mock, err := MockeryE(configFunc)
+	if err != nil {
+		t.Fatalf("the mockery has configuration errors:\n%s", err.Error())
+		return nil
+	}
+	return ServeMock(t, mock)
+}
+
+// ServeMock is like StartServer but serves a mock that has already been built, e.g. by New.
+func ServeMock(t TestingT, mock Mock) *TestServer {
+	t.Helper()
+	server := &TestServer{Server: httptest.NewServer(mock), Mock: mock}
+	t.Cleanup(func() {
+		t.Helper()
+		server.Close()
+		if err := server.checkUnmatched(); err != nil {
+			t.Errorf("%s", err.Error())
+		}
+		if err := server.checkInvalid(); err != nil {
+			t.Errorf("%s", err.Error())
+		}
+		server.AssertExpectations(t)
+	})
+	return server
+}
+
+// checkUnmatched returns an error listing the requests that no endpoint matched, if there are any.  They include
+// requests an endpoint got with a method it has no Method for.  The mockery counts them as they are served, so they
+// are found even if the journal is disabled or has discarded them.
+func (s *TestServer) checkUnmatched() error {
+	var count int
+	var lines []string
+	if m, ok := s.Mock.(*mockery); ok {
+		count, lines = m.unmatchedRequests()
+	} else {
+		for _, rr := range s.UnmatchedRequests() {
+			lines = append(lines, rr.Method+" "+rr.URL.RequestURI())
+		}
+		count = len(lines)
+	}
+	if count == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i] = "  " + lines[i]
+	}
+	if count > len(lines) {
+		lines = append(lines, fmt.Sprintf("  ... and %d more", count-len(lines)))
+	}
+	return fmt.Errorf("%d unexpected request(s) matched no endpoint:\n%s", count, strings.Join(lines, "\n"))
+}
+
+// checkInvalid returns an error listing the requests in the journal that ValidateRequestAgainst found violations in,
+// with their violations, if there are any.
+func (s *TestServer) checkInvalid() error {
+	invalid := s.InvalidRequests()
+	if len(invalid) == 0 {
+		return nil
+	}
+	var lines []string
+	for _, rr := range invalid {
+		lines = append(lines, fmt.Sprintf("  %s %s", rr.Method, rr.URL.RequestURI()))
+		for _, v := range rr.Violations {
+			lines = append(lines, "    "+v.String())
+		}
+	}
+	return fmt.Errorf("%d invalid request(s):\n%s", len(invalid), strings.Join(lines, "\n"))
+}
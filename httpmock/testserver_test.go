@@ -0,0 +1,163 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"fmt"
+	"github.com/bluesoftdev/go-http-matchers/extractor"
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// fakeT records the failures reported by StartServer so that they can be checked.
+type fakeT struct {
+	errors   []string
+	fatals   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.fatals = append(t.fatals, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *fakeT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func ordersConfig() {
+	Endpoint("/orders", func() {
+		Method("GET", func() {
+			RespondWithString(200, "orders")
+		})
+	})
+}
+
+func TestStartServer(t *testing.T) {
+	server := StartServer(t, ordersConfig)
+
+	response, err := http.Get(server.URL + "/orders")
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, 200, response.StatusCode)
+	}
+	assert.Len(t, server.Requests(), 1)
+	assert.Empty(t, server.UnmatchedRequests())
+}
+
+func TestStartServerUnmatched(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, ordersConfig)
+
+	for _, path := range []string{"/orders", "/customers?id=1"} {
+		response, err := http.Get(server.URL + path)
+		if assert.NoError(t, err) {
+			response.Body.Close()
+		}
+	}
+	ft.finish()
+	assert.Empty(t, ft.fatals)
+	assert.Equal(t, []string{"1 unexpected request(s) matched no endpoint:\n  GET /customers?id=1"}, ft.errors)
+
+	_, err := http.Get(server.URL + "/orders")
+	assert.Error(t, err, "the server must be closed when the test completes")
+}
+
+func TestStartServerUnmatchedMethod(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, ordersConfig)
+
+	response, err := http.Post(server.URL+"/orders", "text/plain", nil)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, 404, response.StatusCode)
+	}
+	ft.finish()
+	assert.Equal(t, []string{"1 unexpected request(s) matched no endpoint:\n  POST /orders"}, ft.errors)
+}
+
+func TestStartServerSwitchWithoutDefault(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				Switch(extractor.ExtractQueryParameter("id"), func() {
+					Case(predicate.StringEquals("1"), func() {
+						RespondWithString(200, "order 1")
+					})
+				})
+			})
+		})
+	})
+
+	response, err := http.Get(server.URL + "/orders?id=2")
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, 404, response.StatusCode)
+	}
+	ft.finish()
+	assert.Empty(t, ft.errors)
+}
+
+func TestStartServerUnmatchedWithoutJournal(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, func() {
+		JournalCapacity(0)
+		ordersConfig()
+	})
+
+	for i := 0; i < 12; i++ {
+		response, err := http.Get(fmt.Sprintf("%s/customers/%d", server.URL, i))
+		if assert.NoError(t, err) {
+			response.Body.Close()
+		}
+	}
+	ft.finish()
+	if assert.Len(t, ft.errors, 1) {
+		assert.Contains(t, ft.errors[0], "12 unexpected request(s) matched no endpoint:\n  GET /customers/0\n")
+		assert.Contains(t, ft.errors[0], "\n  ... and 2 more")
+	}
+}
+
+func TestStartServerConfigErrors(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, func() {
+		FixedDelay("soon")
+	})
+	assert.Nil(t, server)
+	if assert.Len(t, ft.fatals, 1) {
+		assert.Contains(t, ft.fatals[0], "invalid delay for FixedDelay")
+	}
+	assert.Empty(t, ft.cleanups)
+}
+
+func TestServeMock(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/orders", func() {
+			b.Method("GET", func() {
+				b.RespondWithString(200, "orders")
+			})
+		})
+	})
+	ft := &fakeT{}
+	server := ServeMock(ft, mock)
+	response, err := http.Get(server.URL + "/orders")
+	if assert.NoError(t, err) {
+		response.Body.Close()
+	}
+	ft.finish()
+	assert.Empty(t, ft.errors)
+}
@@ -0,0 +1,148 @@
+package httpmock
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"time"
+)
+
+// bodyPacer controls how fast the body of a response is delivered, see ThrottleBandwidth and ChunkedDribbleDelay.
+type bodyPacer interface {
+	// write writes the part of the body given, it may hold it back until finish is called.
+	write(w http.ResponseWriter, p []byte) (int, error)
+	// finish is called once the handler has returned to deliver what has been held back.
+	finish(w http.ResponseWriter)
+}
+
+type bandwidthPacer struct {
+	bytesPerSecond int
+	start          time.Time
+	written        int
+}
+
+// ThrottleBandwidth limits the rate at which the body of the response is written to the bytes per second given, to
+// simulate a slow network.  The body is flushed in slices of a tenth of a second's worth of bytes.  It may be used
+// before or after the response element, e.g.
+//
+//    Method("GET", func() {
+//      RespondWithFile(200, "./large.json")
+//      ThrottleBandwidth(16 * 1024)
+//    })
+//
+func ThrottleBandwidth(bytesPerSecond int) {
+	CurrentBuilder().ThrottleBandwidth(bytesPerSecond)
+}
+
+// ThrottleBandwidth limits the rate at which the body of the response is written to the bytes per second given.
+func (b *Builder) ThrottleBandwidth(bytesPerSecond int) {
+	if bytesPerSecond <= 0 {
+		b.Errorf("bytesPerSecond for ThrottleBandwidth must be greater than 0 but is %d", bytesPerSecond)
+		return
+	}
+	b.pace(func() bodyPacer { return &bandwidthPacer{bytesPerSecond: bytesPerSecond} })
+}
+
+func (p *bandwidthPacer) write(w http.ResponseWriter, data []byte) (int, error) {
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	slice := maxInt(1, p.bytesPerSecond/10)
+	n := 0
+	for len(data) > 0 {
+		m, err := w.Write(data[:minInt(slice, len(data))])
+		n += m
+		p.written += m
+		if err != nil {
+			return n, err
+		}
+		flush(w)
+		data = data[m:]
+		due := p.start.Add(time.Duration(float64(p.written) / float64(p.bytesPerSecond) * float64(time.Second)))
+		time.Sleep(time.Until(due))
+	}
+	return n, nil
+}
+
+func (p *bandwidthPacer) finish(w http.ResponseWriter) {
+}
+
+type dribblePacer struct {
+	chunks   int
+	duration time.Duration
+	body     bytes.Buffer
+}
+
+// ChunkedDribbleDelay delivers the body of the response in the number of chunks given spread evenly over the
+// duration, like wiremock's chunkedDribbleDelay.  The status and headers are sent straight away, each chunk is sent
+// after a further duration/chunks.  The duration is in the format expected by time.ParseDuration, e.g.
+//
+//    Method("GET", func() {
+//      RespondWithString(200, "a body that arrives slowly")
+//      ChunkedDribbleDelay(5, "1s")
+//    })
+//
+func ChunkedDribbleDelay(chunks int, duration string) {
+	CurrentBuilder().ChunkedDribbleDelay(chunks, duration)
+}
+
+// ChunkedDribbleDelay delivers the body of the response in the number of chunks given spread evenly over the
+// duration.
+func (b *Builder) ChunkedDribbleDelay(chunks int, duration string) {
+	d, ok := b.parseDuration("ChunkedDribbleDelay", "duration", duration)
+	if !ok {
+		return
+	}
+	if chunks <= 0 {
+		b.Errorf("chunks for ChunkedDribbleDelay must be greater than 0 but is %d", chunks)
+		return
+	}
+	b.pace(func() bodyPacer { return &dribblePacer{chunks: chunks, duration: d} })
+}
+
+func (p *dribblePacer) write(w http.ResponseWriter, data []byte) (int, error) {
+	return p.body.Write(data)
+}
+
+func (p *dribblePacer) finish(w http.ResponseWriter) {
+	flush(w)
+	body := p.body.Bytes()
+	interval := p.duration / time.Duration(p.chunks)
+	for i := 0; i < p.chunks; i++ {
+		time.Sleep(interval)
+		chunk := body[i*len(body)/p.chunks : (i+1)*len(body)/p.chunks]
+		if len(chunk) == 0 {
+			continue
+		}
+		if _, err := w.Write(chunk); err != nil {
+			log.Printf("ERROR while dribbling the response body: %+v", err)
+			return
+		}
+		flush(w)
+	}
+}
+
+// pace decorates the current handler so that the body of the response is delivered by a pacer made for each request.
+func (b *Builder) pace(newPacer func() bodyPacer) {
+	b.DecorateHandlerBefore(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		rw, ok := w.(*recordingResponseWriter)
+		if !ok {
+			log.Printf("ERROR while pacing the response body: the ResponseWriter was not created by the mockery")
+			return
+		}
+		rw.pacer = newPacer()
+	}))
+}
+
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottleBandwidth(t *testing.T) {
+	body := strings.Repeat("x", 300)
+	mock := Mockery(func() {
+		Endpoint("/after", func() {
+			Method("GET", func() {
+				RespondWithString(200, body)
+				ThrottleBandwidth(1000)
+			})
+		})
+		Endpoint("/before", func() {
+			Method("GET", func() {
+				ThrottleBandwidth(1000)
+				RespondWithString(200, body)
+			})
+		})
+	})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	for _, path := range []string{"/after", "/before"} {
+		start := time.Now()
+		response, err := http.Get(server.URL + path)
+		if assert.NoError(t, err) {
+			received, err := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(received))
+		}
+		elapsed := time.Since(start)
+		assert.True(t, elapsed >= 250*time.Millisecond, "%s took %s", path, elapsed)
+		assert.True(t, elapsed < 2*time.Second, "%s took %s", path, elapsed)
+	}
+}
+
+func TestChunkedDribbleDelay(t *testing.T) {
+	mock := New(func(b *Builder) {
+		b.Endpoint("/dribble", func() {
+			b.Method("GET", func() {
+				b.ChunkedDribbleDelay(3, "300ms")
+				b.RespondWithString(200, "abcdefghi")
+			})
+		})
+	})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	start := time.Now()
+	response, err := http.Get(server.URL + "/dribble")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+	assert.Equal(t, 200, response.StatusCode)
+	assert.True(t, time.Since(start) < 250*time.Millisecond, "the status took %s", time.Since(start))
+	chunks := make([]string, 0, 3)
+	buf := make([]byte, 16)
+	for {
+		n, err := response.Body.Read(buf)
+		if n > 0 {
+			chunks = append(chunks, string(buf[:n]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, []string{"abc", "def", "ghi"}, chunks)
+	assert.True(t, time.Since(start) >= 250*time.Millisecond, "the body took %s", time.Since(start))
+	assert.Equal(t, 200, mock.Requests()[0].Status)
+}
+
+func TestThrottleConfigErrors(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		b.ThrottleBandwidth(0)
+		b.ChunkedDribbleDelay(0, "1s")
+		b.ChunkedDribbleDelay(5, "soon")
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 3) {
+		assert.Equal(t, "bytesPerSecond for ThrottleBandwidth must be greater than 0 but is 0", err.(ConfigErrors)[0].Message)
+		assert.Equal(t, "chunks for ChunkedDribbleDelay must be greater than 0 but is 0", err.(ConfigErrors)[1].Message)
+		assert.Contains(t, err.(ConfigErrors)[2].Message, "invalid duration for ChunkedDribbleDelay: ")
+	}
+}
@@ -0,0 +1,227 @@
+package httpmock
+
+import (
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// certificateValidity is how long the certificates issued by a CertificateAuthority are valid for.
+const certificateValidity = 24 * time.Hour
+
+// CertificateAuthority is a self-signed CA generated at runtime, it issues the certificates used to serve mocks over
+// TLS and the client certificates used to call them with mutual TLS.
+type CertificateAuthority struct {
+	// Certificate is the CA's certificate.
+	Certificate *x509.Certificate
+	// PEM is the CA's certificate PEM encoded, so that clients can be configured to trust it.
+	PEM []byte
+
+	key *ecdsa.PrivateKey
+}
+
+// NewCertificateAuthority generates a self-signed CA with the common name given.
+func NewCertificateAuthority(commonName string) (*CertificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template, err := certificateTemplate(commonName)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateAuthority{
+		Certificate: certificate,
+		PEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:         key,
+	}, nil
+}
+
+// IssueCertificate issues a certificate signed by the CA with the common name given.  The hosts are added to the
+// certificate's subject alternative names, as IP addresses if they parse as one and as DNS names otherwise.  The
+// certificate may be used by servers and by clients.
+func (ca *CertificateAuthority) IssueCertificate(commonName string, hosts ...string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template, err := certificateTemplate(commonName)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// CertPool returns a pool holding the CA's certificate, for use as the RootCAs of clients or the ClientCAs of servers.
+func (ca *CertificateAuthority) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+func certificateTemplate(commonName string) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Mockery"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certificateValidity),
+	}, nil
+}
+
+// TLSServer is an httptest.Server that serves a mock over HTTPS with a certificate issued by a CertificateAuthority
+// generated when it starts.  Its Client trusts the CA, other clients can be given CA.PEM.
+type TLSServer struct {
+	*httptest.Server
+	// CA is the certificate authority that issued the server's certificate and, with mutual TLS, the certificates the
+	// server accepts from clients.
+	CA *CertificateAuthority
+}
+
+// NewTLSServer starts serving the handler over HTTPS on a loopback address, e.g.
+//
+//    mock := Mockery(func() { ... })
+//    server, err := NewTLSServer(mock)
+//    if err != nil {
+//      t.Fatal(err)
+//    }
+//    defer server.Close()
+//    response, err := server.Client().Get(server.URL + "/orders")
+//
+func NewTLSServer(handler http.Handler) (*TLSServer, error) {
+	return newTLSServer(handler, tls.NoClientCert)
+}
+
+// NewMutualTLSServer is like NewTLSServer but the server requires the clients to present a certificate issued by its
+// CA, see ClientWithCertificate.  The ClientCertificateSubject and ClientCertificateSAN predicates can be used to
+// select endpoints by the certificate presented.
+func NewMutualTLSServer(handler http.Handler) (*TLSServer, error) {
+	return newTLSServer(handler, tls.RequireAndVerifyClientCert)
+}
+
+func newTLSServer(handler http.Handler, clientAuth tls.ClientAuthType) (*TLSServer, error) {
+	ca, err := NewCertificateAuthority("Mockery CA")
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := ca.IssueCertificate("Mockery", "localhost", "127.0.0.1", "::1")
+	if err != nil {
+		return nil, err
+	}
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   clientAuth,
+		ClientCAs:    ca.CertPool(),
+	}
+	server.StartTLS()
+	server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs = ca.CertPool()
+	return &TLSServer{Server: server, CA: ca}, nil
+}
+
+// ClientWithCertificate returns a client that trusts the server and presents a client certificate issued by the CA
+// with the common name and subject alternative names given.
+func (s *TLSServer) ClientWithCertificate(commonName string, sans ...string) (*http.Client, error) {
+	certificate, err := s.CA.IssueCertificate(commonName, sans...)
+	if err != nil {
+		return nil, err
+	}
+	transport := s.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.Certificates = []tls.Certificate{certificate}
+	return &http.Client{Transport: transport}, nil
+}
+
+// ClientCertificateSubject returns a predicate that applies the string predicate given to the subject of the
+// certificate the client presented, in the form "CN=billing,O=Acme".  It rejects requests without a client
+// certificate, e.g.
+//
+//    EndpointForCondition(ClientCertificateSubject(StringContains("CN=billing")), func() {
+//      RespondWithString(200, "hello billing")
+//    })
+//
+func ClientCertificateSubject(p predicate.Predicate) predicate.Predicate {
+	return Describe(fmt.Sprintf("client certificate subject meets %s", describe(p)),
+		predicate.PredicateFunc(func(r interface{}) bool {
+			certificate := clientCertificate(r)
+			return certificate != nil && p.Accept(certificate.Subject.String())
+		}))
+}
+
+// ClientCertificateSAN returns a predicate that accepts requests whose client certificate has a subject alternative
+// name, a DNS name, email address, IP address or URI, that the string predicate given accepts.  It rejects requests
+// without a client certificate.
+func ClientCertificateSAN(p predicate.Predicate) predicate.Predicate {
+	return Describe(fmt.Sprintf("a client certificate SAN meets %s", describe(p)),
+		predicate.PredicateFunc(func(r interface{}) bool {
+			certificate := clientCertificate(r)
+			if certificate == nil {
+				return false
+			}
+			sans := append(append([]string(nil), certificate.DNSNames...), certificate.EmailAddresses...)
+			for _, ip := range certificate.IPAddresses {
+				sans = append(sans, ip.String())
+			}
+			for _, uri := range certificate.URIs {
+				sans = append(sans, uri.String())
+			}
+			for _, san := range sans {
+				if p.Accept(san) {
+					return true
+				}
+			}
+			return false
+		}))
+}
+
+// clientCertificate returns the certificate the client presented with the request, or nil.
+func clientCertificate(r interface{}) *x509.Certificate {
+	request, ok := r.(*http.Request)
+	if !ok || request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return request.TLS.PeerCertificates[0]
+}
@@ -0,0 +1,100 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/go-http-matchers/predicate"
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func get(client *http.Client, url string) (int, string, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	return response.StatusCode, string(body), err
+}
+
+func TestTLSServer(t *testing.T) {
+	mock := Mockery(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				RespondWithString(200, "orders")
+			})
+		})
+	})
+	server, err := NewTLSServer(mock)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+
+	status, body, err := get(server.Client(), server.URL+"/orders")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "orders", body)
+
+	_, _, err = get(&http.Client{}, server.URL+"/orders")
+	assert.Error(t, err)
+
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(server.CA.PEM))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	status, _, err = get(client, server.URL+"/orders")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+}
+
+func TestMutualTLSServer(t *testing.T) {
+	mock := Mockery(func() {
+		EndpointForCondition(ClientCertificateSubject(StringContains("CN=billing")), func() {
+			RespondWithString(200, "billing")
+		})
+		EndpointForCondition(ClientCertificateSAN(StringEquals("shipping.internal")), func() {
+			RespondWithString(200, "shipping")
+		})
+	})
+	server, err := NewMutualTLSServer(mock)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+
+	billing, err := server.ClientWithCertificate("billing")
+	if assert.NoError(t, err) {
+		_, body, err := get(billing, server.URL+"/")
+		assert.NoError(t, err)
+		assert.Equal(t, "billing", body)
+	}
+
+	shipping, err := server.ClientWithCertificate("shipper", "shipping.internal")
+	if assert.NoError(t, err) {
+		_, body, err := get(shipping, server.URL+"/")
+		assert.NoError(t, err)
+		assert.Equal(t, "shipping", body)
+	}
+
+	other, err := server.ClientWithCertificate("other", "other.internal")
+	if assert.NoError(t, err) {
+		status, _, err := get(other, server.URL+"/")
+		assert.NoError(t, err)
+		assert.Equal(t, 404, status)
+	}
+
+	_, _, err = get(server.Client(), server.URL+"/")
+	assert.Error(t, err, "a client without a certificate must be rejected")
+}
+
+func TestClientCertificatePredicatesWithoutTLS(t *testing.T) {
+	request := httptest.NewRequest("GET", "/", nil)
+	assert.False(t, ClientCertificateSubject(True()).Accept(request))
+	assert.False(t, ClientCertificateSAN(True()).Accept(request))
+}
@@ -0,0 +1,100 @@
+package httpmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// RequestViolation is a way in which a request does not meet the description of the service that
+// ValidateRequestAgainst checks it against.
+type RequestViolation struct {
+	// In is the part of the request with the problem: "path", "method", "query", "header", "cookie" or "body".
+	In string `json:"in"`
+	// Name is the name of the parameter, or the JSON pointer to the part of the body, with the problem.  It is empty if
+	// the problem is with the whole part, e.g. a body that is missing.
+	Name string `json:"name,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+func (v RequestViolation) String() string {
+	if v.Name == "" {
+		return fmt.Sprintf("%s: %s", v.In, v.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", v.In, v.Name, v.Message)
+}
+
+// RequestValidator checks requests against a description of the service, e.g. an OpenAPI document loaded by
+// openapi.LoadSpec.
+type RequestValidator interface {
+	// ValidateRequest returns the ways in which the request does not meet the description, or nil if it meets it.  It
+	// must leave the request's body to be read again.
+	ValidateRequest(request *http.Request) []RequestViolation
+}
+
+// ValidationOption modifies what ValidateRequestAgainst does with a request that is not valid.
+type ValidationOption int
+
+const (
+	// RecordViolationsOnly records the violations in the journal and serves the response as if the request were
+	// valid, instead of responding 400.
+	RecordViolationsOnly ValidationOption = iota
+)
+
+// violationsResponse is the body of the 400 response ValidateRequestAgainst sends.
+type violationsResponse struct {
+	Message    string             `json:"message"`
+	Violations []RequestViolation `json:"violations"`
+}
+
+// ValidateRequestAgainst checks each request against the validator, e.g. an OpenAPI document, so that the mock rejects
+// the requests the real service would.  The violations are recorded in the request journal, see
+// RecordedRequest.Violations, and a request that has any gets a 400 response with a JSON body listing them, e.g.
+//
+//    {"message": "the request is not valid", "violations": [{"in": "query", "name": "limit", "message": "must be an integer"}]}
+//
+// With RecordViolationsOnly the response is served as usual instead.  Like RateLimit it must be used after the
+// response has been specified, e.g.
+//
+//    spec, err := openapi.LoadSpec("./petstore.yaml")
+//    ...
+//    Endpoint("/pets", func() {
+//      Method("POST", func() {
+//        RespondWithJson(201, pet)
+//        ValidateRequestAgainst(spec)
+//      })
+//    })
+//
+func ValidateRequestAgainst(validator RequestValidator, options ...ValidationOption) {
+	CurrentBuilder().ValidateRequestAgainst(validator, options...)
+}
+
+// ValidateRequestAgainst checks each request against the validator, e.g. an OpenAPI document.
+func (b *Builder) ValidateRequestAgainst(validator RequestValidator, options ...ValidationOption) {
+	if validator == nil {
+		b.Errorf("the validator for ValidateRequestAgainst must not be nil")
+		return
+	}
+	recordOnly := false
+	for _, option := range options {
+		if option == RecordViolationsOnly {
+			recordOnly = true
+		}
+	}
+	delegate := b.handler
+	b.handler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		violations := validator.ValidateRequest(request)
+		if rw, ok := w.(*recordingResponseWriter); ok {
+			rw.violations = append(rw.violations, violations...)
+		}
+		if len(violations) == 0 || recordOnly {
+			delegate.ServeHTTP(w, request)
+			return
+		}
+		body, _ := json.Marshal(violationsResponse{"the request is not valid", violations})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(body)
+	})
+}
@@ -0,0 +1,94 @@
+package httpmock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// idValidator requires the "id" query parameter.
+type idValidator struct{}
+
+func (idValidator) ValidateRequest(request *http.Request) []RequestViolation {
+	if request.URL.Query().Get("id") == "" {
+		return []RequestViolation{{In: "query", Name: "id", Message: "is required"}}
+	}
+	return nil
+}
+
+func validatedConfig(options ...ValidationOption) func() {
+	return func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				RespondWithString(200, "orders")
+				ValidateRequestAgainst(idValidator{}, options...)
+			})
+		})
+	}
+}
+
+func TestValidateRequestAgainst(t *testing.T) {
+	mock := Mockery(validatedConfig())
+
+	code, body := serve(mock, "GET", "/orders?id=1")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "orders", body)
+
+	code, body = serve(mock, "GET", "/orders")
+	assert.Equal(t, 400, code)
+	assert.JSONEq(t, `{"message": "the request is not valid",
+		"violations": [{"in": "query", "name": "id", "message": "is required"}]}`, body)
+
+	requests := mock.Requests()
+	if assert.Len(t, requests, 2) {
+		assert.Empty(t, requests[0].Violations)
+		assert.Equal(t, []RequestViolation{{In: "query", Name: "id", Message: "is required"}}, requests[1].Violations)
+	}
+	invalid := mock.InvalidRequests()
+	if assert.Len(t, invalid, 1) {
+		assert.Equal(t, "/orders", invalid[0].URL.RequestURI())
+	}
+}
+
+func TestValidateRequestAgainstRecordOnly(t *testing.T) {
+	mock := Mockery(validatedConfig(RecordViolationsOnly))
+
+	code, body := serve(mock, "GET", "/orders")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "orders", body)
+	invalid := mock.InvalidRequests()
+	if assert.Len(t, invalid, 1) {
+		assert.Equal(t, "query id: is required", invalid[0].Violations[0].String())
+	}
+}
+
+func TestValidateRequestAgainstConfigErrors(t *testing.T) {
+	_, err := MockeryE(func() {
+		Endpoint("/orders", func() {
+			Method("GET", func() {
+				Respond(200)
+				ValidateRequestAgainst(nil)
+			})
+		})
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the validator for ValidateRequestAgainst must not be nil")
+	}
+}
+
+func TestStartServerInvalidRequests(t *testing.T) {
+	ft := &fakeT{}
+	server := StartServer(ft, validatedConfig(RecordViolationsOnly))
+
+	for _, path := range []string{"/orders?id=1", "/orders?name=x"} {
+		response, err := http.Get(server.URL + path)
+		if assert.NoError(t, err) {
+			response.Body.Close()
+			assert.Equal(t, 200, response.StatusCode)
+		}
+	}
+	ft.finish()
+	assert.Equal(t, []string{"1 invalid request(s):\n  GET /orders?name=x\n    query id: is required"}, ft.errors)
+}
@@ -0,0 +1,227 @@
+package wiremock
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bluesoftdev/mockery/httpmock"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AdminLocation is the location recorded for the endpoints added through the admin API.
+const AdminLocation = "admin API"
+
+type adminHandler struct {
+	mock        httpmock.Mock
+	dataDirName string
+}
+
+type adminMapping struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	Location string `json:"location"`
+}
+
+type adminMappings struct {
+	Mappings []adminMapping `json:"mappings"`
+	Meta     adminMeta      `json:"meta"`
+}
+
+type adminMeta struct {
+	Total int `json:"total"`
+}
+
+type adminRequest struct {
+	Timestamp     time.Time   `json:"timestamp"`
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Headers       http.Header `json:"headers"`
+	Body          string      `json:"body"`
+	Endpoint      string      `json:"endpoint"`
+	Status        int         `json:"status"`
+	LatencyMillis float64     `json:"latencyMillis"`
+}
+
+type adminRequests struct {
+	Requests []adminRequest `json:"requests"`
+	Meta     adminMeta      `json:"meta"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// AdminHandler returns a handler that serves an admin API for the mock.  The API manages the endpoints of the mock
+// at runtime using wiremock mappings, files named by the mappings are looked for in dataDirName.  The paths below are
+// relative to the handler, see WithAdmin to mount it under a prefix:
+//
+//    GET    /mappings        lists the endpoints with their id, priority and the location they were defined.
+//    POST   /mappings        adds the endpoint defined by the wiremock mapping in the body.
+//    GET    /mappings/{id}   describes the endpoint.
+//    PUT    /mappings/{id}   replaces the endpoint with the one defined by the wiremock mapping in the body.
+//    DELETE /mappings/{id}   removes the endpoint.
+//    GET    /requests        lists the requests in the journal.
+//    DELETE /requests        resets the journal.
+//    POST   /scenarios/reset resets the scenarios.
+//    POST   /reset           restores the endpoints the mock was built with, or last reloaded, and resets the journal
+//                            and scenarios.
+func AdminHandler(mock httpmock.Mock, dataDirName string) http.Handler {
+	return &adminHandler{mock: mock, dataDirName: dataDirName}
+}
+
+// WithAdmin returns a handler that serves the admin API, see AdminHandler, for requests whose path starts with the
+// prefix given, e.g. "/__admin", and passes all other requests to the mock.
+func WithAdmin(mock httpmock.Mock, prefix, dataDirName string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	admin := http.StripPrefix(prefix, AdminHandler(mock, dataDirName))
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == prefix || strings.HasPrefix(request.URL.Path, prefix+"/") {
+			admin.ServeHTTP(w, request)
+		} else {
+			mock.ServeHTTP(w, request)
+		}
+	})
+}
+
+func (a *adminHandler) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	path := strings.Trim(request.URL.Path, "/")
+	switch {
+	case path == "mappings" && request.Method == "GET":
+		a.listMappings(w)
+	case path == "mappings" && request.Method == "POST":
+		a.defineMapping(w, request, "", http.StatusCreated)
+	case strings.HasPrefix(path, "mappings/") && path != "mappings/reset":
+		id := strings.TrimPrefix(path, "mappings/")
+		switch request.Method {
+		case "GET":
+			a.getMapping(w, id)
+		case "PUT":
+			if _, ok := a.findMapping(id); !ok {
+				writeAdminError(w, http.StatusNotFound, fmt.Errorf("there is no endpoint with id %s", id))
+				return
+			}
+			a.defineMapping(w, request, id, http.StatusOK)
+		case "DELETE":
+			if err := a.mock.RemoveEndpoint(id); err != nil {
+				writeAdminError(w, http.StatusNotFound, err)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case path == "requests" && request.Method == "GET":
+		a.listRequests(w)
+	case path == "requests" && request.Method == "DELETE":
+		a.mock.ResetJournal()
+		w.WriteHeader(http.StatusOK)
+	case path == "scenarios/reset" && request.Method == "POST":
+		a.mock.ResetScenarios()
+		w.WriteHeader(http.StatusOK)
+	case (path == "reset" || path == "mappings/reset") && request.Method == "POST":
+		a.mock.Reset()
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (a *adminHandler) listMappings(w http.ResponseWriter) {
+	endpoints := a.mock.Endpoints()
+	mappings := adminMappings{Mappings: make([]adminMapping, len(endpoints)), Meta: adminMeta{Total: len(endpoints)}}
+	for i, e := range endpoints {
+		mappings.Mappings[i] = toAdminMapping(e)
+	}
+	writeAdminJSON(w, http.StatusOK, &mappings)
+}
+
+func (a *adminHandler) findMapping(id string) (httpmock.EndpointInfo, bool) {
+	for _, e := range a.mock.Endpoints() {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return httpmock.EndpointInfo{}, false
+}
+
+func (a *adminHandler) getMapping(w http.ResponseWriter, id string) {
+	e, ok := a.findMapping(id)
+	if !ok {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("there is no endpoint with id %s", id))
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, toAdminMapping(e))
+}
+
+// defineMapping adds or replaces the endpoint defined by the mapping in the request body.  If the id is empty the id
+// in the mapping is used, if there is none a new id is assigned.
+func (a *adminHandler) defineMapping(w http.ResponseWriter, request *http.Request, id string, status int) {
+	wm, err := parseWireMock(request.Body)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	if id == "" {
+		id = wm.Id
+	}
+	if id == "" {
+		id = wm.Uuid
+	}
+	id, err = a.define(id, wm)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	e, _ := a.findMapping(id)
+	writeAdminJSON(w, status, toAdminMapping(e))
+}
+
+func (a *adminHandler) define(id string, wm *wireMock) (string, error) {
+	configFunc := func(b *httpmock.Builder) {
+		b.DefinedAt(AdminLocation, func() {
+			addWireMock(b, a.dataDirName, wm)
+		})
+	}
+	if id == "" {
+		ids, err := a.mock.AddEndpoints(configFunc)
+		if err != nil {
+			return "", err
+		}
+		return ids[0], nil
+	}
+	return id, a.mock.SetEndpoint(id, configFunc)
+}
+
+func (a *adminHandler) listRequests(w http.ResponseWriter) {
+	recorded := a.mock.Requests()
+	requests := adminRequests{Requests: make([]adminRequest, len(recorded)), Meta: adminMeta{Total: len(recorded)}}
+	for i, rr := range recorded {
+		requests.Requests[i] = adminRequest{
+			Timestamp:     rr.Timestamp,
+			Method:        rr.Method,
+			URL:           rr.URL.RequestURI(),
+			Headers:       rr.Header,
+			Body:          string(rr.Body),
+			Endpoint:      rr.Endpoint,
+			Status:        rr.Status,
+			LatencyMillis: float64(rr.Latency) / float64(time.Millisecond),
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, &requests)
+}
+
+func toAdminMapping(e httpmock.EndpointInfo) adminMapping {
+	return adminMapping{ID: e.ID, Name: e.Name, Priority: e.Priority, Location: e.Location}
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, &adminError{Error: err.Error()})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
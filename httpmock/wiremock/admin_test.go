@@ -0,0 +1,143 @@
+package wiremock_test
+
+import (
+	"encoding/json"
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/wiremock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type mappingsResponse struct {
+	Mappings []struct {
+		ID       string
+		Name     string
+		Priority int
+		Location string
+	}
+	Meta struct {
+		Total int
+	}
+}
+
+func adminRequest(t *testing.T, handler http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	var request *http.Request
+	if body != "" {
+		request = httptest.NewRequest(method, url, strings.NewReader(body))
+	} else {
+		request = httptest.NewRequest(method, url, nil)
+	}
+	responseWriter := httptest.NewRecorder()
+	handler.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func listMappings(t *testing.T, handler http.Handler) mappingsResponse {
+	var mappings mappingsResponse
+	responseWriter := adminRequest(t, handler, "GET", "/__admin/mappings", "")
+	assert.Equal(t, 200, responseWriter.Code)
+	assert.NoError(t, json.Unmarshal(responseWriter.Body.Bytes(), &mappings))
+	return mappings
+}
+
+const adminMapping = `{
+  "request": {"method": "GET", "url": "/admin/test"},
+  "response": {"status": 200, "body": "added"}
+}`
+
+func TestAdminMappings(t *testing.T) {
+	handler := WithAdmin(Mockery(func() {
+		Endpoint("/foo", func() {
+			Method("GET", func() {
+				RespondWithString(200, "foo")
+			})
+		})
+		WireMockEndpoint("__files", "mappings/testmapping.json")
+	}), "/__admin", "__files")
+
+	mappings := listMappings(t, handler)
+	if assert.Equal(t, 2, mappings.Meta.Total) {
+		assert.Equal(t, "/foo", mappings.Mappings[0].Name)
+		assert.Equal(t, 100, mappings.Mappings[0].Priority)
+		assert.Contains(t, mappings.Mappings[0].Location, "admin_test.go")
+		assert.Equal(t, "mappings/testmapping.json", mappings.Mappings[1].Location)
+	}
+
+	assert.Equal(t, 404, adminRequest(t, handler, "GET", "/admin/test", "").Code)
+
+	responseWriter := adminRequest(t, handler, "POST", "/__admin/mappings", adminMapping)
+	assert.Equal(t, 201, responseWriter.Code)
+	var added struct{ ID, Location string }
+	assert.NoError(t, json.Unmarshal(responseWriter.Body.Bytes(), &added))
+	assert.Equal(t, AdminLocation, added.Location)
+	assert.Equal(t, 3, listMappings(t, handler).Meta.Total)
+
+	responseWriter = adminRequest(t, handler, "GET", "/admin/test", "")
+	assert.Equal(t, 200, responseWriter.Code)
+	assert.Equal(t, "added", responseWriter.Body.String())
+
+	responseWriter = adminRequest(t, handler, "PUT", "/__admin/mappings/"+added.ID,
+		strings.Replace(adminMapping, `"added"`, `"replaced"`, 1))
+	assert.Equal(t, 200, responseWriter.Code)
+	assert.Equal(t, 3, listMappings(t, handler).Meta.Total)
+	assert.Equal(t, "replaced", adminRequest(t, handler, "GET", "/admin/test", "").Body.String())
+
+	assert.Equal(t, 200, adminRequest(t, handler, "DELETE", "/__admin/mappings/"+added.ID, "").Code)
+	assert.Equal(t, 404, adminRequest(t, handler, "DELETE", "/__admin/mappings/"+added.ID, "").Code)
+	assert.Equal(t, 404, adminRequest(t, handler, "PUT", "/__admin/mappings/"+added.ID, adminMapping).Code)
+	assert.Equal(t, 404, adminRequest(t, handler, "GET", "/admin/test", "").Code)
+
+	// endpoints defined with the DSL may be removed too.
+	assert.Equal(t, 200, adminRequest(t, handler, "DELETE", "/__admin/mappings/"+mappings.Mappings[0].ID, "").Code)
+	assert.Equal(t, 404, adminRequest(t, handler, "GET", "/foo", "").Code)
+	assert.Equal(t, 1, listMappings(t, handler).Meta.Total)
+
+	assert.Equal(t, 400, adminRequest(t, handler, "POST", "/__admin/mappings", "{not json").Code)
+	assert.Equal(t, 400, adminRequest(t, handler, "POST", "/__admin/mappings",
+		`{"request": {"urlPattern": "(unclosed"}, "response": {"status": 200}}`).Code)
+}
+
+func TestAdminMappingWithID(t *testing.T) {
+	handler := WithAdmin(Mockery(func() {}), "/__admin", "__files")
+
+	mapping := `{"id": "my-stub", "request": {"url": "/admin/id"}, "response": {"status": 202}}`
+	assert.Equal(t, 201, adminRequest(t, handler, "POST", "/__admin/mappings", mapping).Code)
+	assert.Equal(t, 201, adminRequest(t, handler, "POST", "/__admin/mappings", mapping).Code)
+	assert.Equal(t, 1, listMappings(t, handler).Meta.Total)
+
+	responseWriter := adminRequest(t, handler, "GET", "/__admin/mappings/my-stub", "")
+	assert.Equal(t, 200, responseWriter.Code)
+	assert.Contains(t, responseWriter.Body.String(), `"id":"my-stub"`)
+	assert.Equal(t, 202, adminRequest(t, handler, "GET", "/admin/id", "").Code)
+}
+
+func TestAdminReset(t *testing.T) {
+	mock := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+	handler := WithAdmin(mock, "/__admin/", "__files")
+	total := listMappings(t, handler).Meta.Total
+
+	assert.Equal(t, 201, adminRequest(t, handler, "POST", "/__admin/mappings", adminMapping).Code)
+	assert.Equal(t, 204, adminRequest(t, handler, "POST", "/testscenario/ship", "").Code)
+	assert.Equal(t, "SHIPPED", adminRequest(t, handler, "GET", "/testscenario/order", "").Body.String())
+
+	responseWriter := adminRequest(t, handler, "GET", "/__admin/requests", "")
+	assert.Equal(t, 200, responseWriter.Code)
+	assert.Contains(t, responseWriter.Body.String(), `"url":"/testscenario/ship"`)
+	assert.Len(t, mock.Requests(), 2)
+
+	assert.Equal(t, 200, adminRequest(t, handler, "POST", "/__admin/scenarios/reset", "").Code)
+	assert.Equal(t, "PENDING", adminRequest(t, handler, "GET", "/testscenario/order", "").Body.String())
+
+	assert.Equal(t, 200, adminRequest(t, handler, "DELETE", "/__admin/requests", "").Code)
+	assert.Empty(t, mock.Requests())
+
+	assert.Equal(t, 200, adminRequest(t, handler, "POST", "/__admin/reset", "").Code)
+	assert.Equal(t, total, listMappings(t, handler).Meta.Total)
+	assert.Equal(t, 404, adminRequest(t, handler, "GET", "/admin/test", "").Code)
+	assert.Equal(t, 404, adminRequest(t, handler, "GET", "/__admin/unknown", "").Code)
+}
@@ -1,7 +1,20 @@
 // Package wiremock provides a mechanism for importing wiremock Json API Mappings into
 // mockery/httpmock.  The implementation, at this time, is incomplete.  It handles
-// file, string, and json based response bodies.  It does not handle any templating.
-// It can handle request matching conditions that include "EqualsTo", "Contains",
-// "Matches" and "DoesNotMatch" for Headers and Query Parameters.  It also supports
-// all types of url matching.
+// file, string, base64 and json based response bodies.  Responses using the
+// "response-template" transformer are translated to httpmock.RespondWithTemplate, the
+// request model (url, path, method, body, query, headers and cookies) and the jsonPath,
+// xPath, now and randomValue helpers are supported.
+// It can handle request matching conditions that include "EqualsTo", "BinaryEqualTo",
+// "Contains", "Matches", "DoesNotMatch" and "absent" for Headers, Query Parameters and
+// Cookies, "basicAuthCredentials" and "bodyPatterns" using those conditions or
+// "equalToJson" (with "ignoreArrayOrder" and "ignoreExtraElements"), "matchesJsonPath",
+// "equalToXml" and "matchesXPath".  JSON paths are limited to member and index steps,
+// see httpmock.ExtractJSONPath.  It also supports all types of url matching and
+// stateful behaviour using "scenarioName", "requiredScenarioState" and
+// "newScenarioState".  Responses with a "proxyBaseUrl" are forwarded to that upstream
+// with httpmock.ProxyTo, a Recorder writes the exchanges of httpmock.RecordingProxyTo
+// as mappings that can be loaded back with WireMockEndpoints.
+// Fields WireMockEndpoints does not understand are ignored, use LoadWireMock to have
+// them reported as errors instead.  WatchWireMockEndpoints reloads the endpoints of a
+// running mock when its mapping files change.
 package wiremock
@@ -0,0 +1,287 @@
+package wiremock
+
+import (
+	"github.com/bluesoftdev/mockery/httpmock"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Stub is a wiremock mapping loaded by LoadWireMock, see WireMockStubs to define its endpoint.
+type Stub struct {
+	// File is the mapping file the stub was loaded from.
+	File string
+	// ID is the id of the mapping, if it has one.
+	ID string
+	// Name is the name of the mapping, if it has one.
+	Name string
+
+	dataDirName string
+	mapping     *wireMock
+}
+
+// MappingError describes a problem with a wiremock mapping file.
+type MappingError struct {
+	// File is the mapping file.
+	File string
+	// Path is the JSON path of the field with the problem, e.g. "$.request.headers.Accept.containz", it is empty if
+	// the problem is with the whole mapping.
+	Path string
+	// Message describes the problem.
+	Message string
+}
+
+func (e *MappingError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Path, e.Message)
+}
+
+// MappingErrors is the error returned by LoadWireMock, it lists every problem found in the mapping files.
+type MappingErrors []*MappingError
+
+func (e MappingErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// LoadWireMock loads the mapping files in the "mappings" subdirectory of dirName, like WireMockEndpoints, but instead
+// of ignoring what it does not understand it returns an error, of type MappingErrors, that reports:
+//
+//    every field that is unknown or not supported, with its JSON path, e.g. "$.response.transformerParameters".
+//    every value that is not supported, with its JSON path, e.g. a delayDistribution type other than "lognormal" or
+//    "uniform", a transformer other than "response-template" or a body matcher, e.g. "equalToJson", used for a
+//    header, query parameter or cookie.
+//    fields that have the wrong type.
+//    values that can't be used, e.g. invalid regular expressions, templates or missing body files.
+//
+// So mappings that would not behave as they do in wiremock are found when the mock starts, e.g.
+//
+//    stubs, err := wiremock.LoadWireMock("./stubs")
+//    if err != nil {
+//      log.Fatal(err)
+//    }
+//    mock := httpmock.Mockery(func() {
+//      wiremock.WireMockStubs(stubs)
+//    })
+//
+func LoadWireMock(dirName string) ([]Stub, error) {
+	mappingDir := dirName + string(os.PathSeparator) + "mappings"
+	dataDir := dirName + string(os.PathSeparator) + "__files"
+	mappingFiles, err := ioutil.ReadDir(mappingDir)
+	if err != nil {
+		return nil, err
+	}
+	stubs := make([]Stub, 0, len(mappingFiles))
+	var errs MappingErrors
+	for _, mappingFile := range mappingFiles {
+		if !mappingFilePattern.MatchString(mappingFile.Name()) {
+			continue
+		}
+		stub, stubErrs := loadStub(dataDir, mappingDir+"/"+mappingFile.Name())
+		if len(stubErrs) > 0 {
+			errs = append(errs, stubErrs...)
+		} else {
+			stubs = append(stubs, *stub)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return stubs, nil
+}
+
+func loadStub(dataDirName, fileName string) (*Stub, MappingErrors) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, MappingErrors{{File: fileName, Message: err.Error()}}
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, MappingErrors{{File: fileName, Message: err.Error()}}
+	}
+	errs := checkFields(fileName, "$", raw, reflect.TypeOf(wireMock{}))
+	errs = append(errs, checkValues(fileName, raw)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	wm, err := parseWireMock(bytes.NewReader(data))
+	if err != nil {
+		return nil, MappingErrors{{File: fileName, Message: err.Error()}}
+	}
+	stub := &Stub{File: fileName, ID: wm.Id, Name: wm.Name, dataDirName: dataDirName, mapping: wm}
+	if stub.ID == "" {
+		stub.ID = wm.Uuid
+	}
+	for _, err := range checkWireMock(dataDirName, wm) {
+		errs = append(errs, &MappingError{File: fileName, Message: err.Message})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return stub, nil
+}
+
+// WireMockStubs defines the endpoints of the stubs loaded by LoadWireMock.
+func WireMockStubs(stubs []Stub) {
+	AddStubs(httpmock.CurrentBuilder(), stubs)
+}
+
+// AddStubs is like WireMockStubs but adds the endpoints to the given builder.
+func AddStubs(b *httpmock.Builder, stubs []Stub) {
+	for i := range stubs {
+		stub := &stubs[i]
+		b.DefinedAt(stub.File, func() {
+			addWireMock(b, stub.dataDirName, stub.mapping)
+		})
+	}
+}
+
+var pathConditionType = reflect.TypeOf(wireMockPathCondition{})
+var valueConditionType = reflect.TypeOf(wireMockValueCondition{})
+var identifierPattern = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+// checkFields reports the fields of the JSON value that the type t, used to decode it, has no field for.
+func checkFields(fileName, path string, value interface{}, t reflect.Type) MappingErrors {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if value == nil {
+		return nil
+	}
+	var errs MappingErrors
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == pathConditionType {
+			if _, ok := value.(string); ok {
+				return nil
+			}
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return MappingErrors{{File: fileName, Path: path, Message: "must be an object"}}
+		}
+		fields := jsonFields(t)
+		if t == pathConditionType {
+			fields = jsonFields(valueConditionType)
+			fields["expression"] = reflect.TypeOf("")
+		}
+		for _, name := range sortedKeys(object) {
+			fieldType, ok := fields[name]
+			if !ok {
+				errs = append(errs, &MappingError{File: fileName, Path: childPath(path, name),
+					Message: "unknown or unsupported field"})
+				continue
+			}
+			errs = append(errs, checkFields(fileName, childPath(path, name), object[name], fieldType)...)
+		}
+	case reflect.Map:
+		if object, ok := value.(map[string]interface{}); ok {
+			for _, name := range sortedKeys(object) {
+				errs = append(errs, checkFields(fileName, childPath(path, name), object[name], t.Elem())...)
+			}
+		}
+	case reflect.Slice:
+		if array, ok := value.([]interface{}); ok {
+			for i, item := range array {
+				errs = append(errs, checkFields(fileName, fmt.Sprintf("%s[%d]", path, i), item, t.Elem())...)
+			}
+		}
+	}
+	return errs
+}
+
+// bodyMatchers are the fields of a condition that only apply to a body, so only "bodyPatterns" may use them.
+var bodyMatchers = map[string]bool{
+	"equalToJson":         true,
+	"ignoreArrayOrder":    true,
+	"ignoreExtraElements": true,
+	"matchesJsonPath":     true,
+	"equalToXml":          true,
+	"matchesXPath":        true,
+}
+
+// supportedDelayDistributions are the values of "delayDistribution.type" that the mappings may use.
+var supportedDelayDistributions = map[string]bool{"lognormal": true, "uniform": true}
+
+// checkValues reports the values of the mapping, decoded from JSON, that are not supported although the fields they
+// are in are.  Values of the wrong type are left to checkFields to report.
+func checkValues(fileName string, raw interface{}) MappingErrors {
+	var errs MappingErrors
+	report := func(path, format string, args ...interface{}) {
+		errs = append(errs, &MappingError{File: fileName, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	mapping, _ := raw.(map[string]interface{})
+	request, _ := mapping["request"].(map[string]interface{})
+	for _, field := range []string{"headers", "queryParameters", "cookies"} {
+		conditions, _ := request[field].(map[string]interface{})
+		for _, name := range sortedKeys(conditions) {
+			condition, _ := conditions[name].(map[string]interface{})
+			for _, matcher := range sortedKeys(condition) {
+				if bodyMatchers[matcher] {
+					report(childPath(childPath(childPath("$.request", field), name), matcher),
+						"%s is only supported in bodyPatterns", matcher)
+				}
+			}
+		}
+	}
+	response, _ := mapping["response"].(map[string]interface{})
+	if distribution, ok := response["delayDistribution"].(map[string]interface{}); ok {
+		if algorithm, ok := distribution["type"].(string); ok && !supportedDelayDistributions[algorithm] {
+			report("$.response.delayDistribution.type",
+				"unsupported delay distribution %q, it must be \"lognormal\" or \"uniform\"", algorithm)
+		}
+	}
+	transformers, _ := response["transformers"].([]interface{})
+	for i, transformer := range transformers {
+		if name, ok := transformer.(string); ok && name != "response-template" {
+			report(fmt.Sprintf("$.response.transformers[%d]", i),
+				"unsupported transformer %q, only \"response-template\" is supported", name)
+		}
+	}
+	return errs
+}
+
+// jsonFields returns the types of the fields of the struct type by the name they have in a mapping file.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			r, size := utf8.DecodeRuneInString(field.Name)
+			name = string(unicode.ToLower(r)) + field.Name[size:]
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func childPath(path, name string) string {
+	if identifierPattern.MatchString(name) {
+		return path + "." + name
+	}
+	return fmt.Sprintf("%s[%q]", path, name)
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package wiremock_test
+
+import (
+	"bytes"
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/wiremock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadWireMock(t *testing.T) {
+	stubs, err := LoadWireMock("testdata/valid")
+	assert.NoError(t, err)
+	if assert.Len(t, stubs, 1) {
+		assert.Equal(t, "6b3c5d9e-0d44-4b0e-8f6e-3c7f2f6a1b10", stubs[0].ID)
+		assert.Equal(t, "binary", stubs[0].Name)
+		assert.Equal(t, "testdata/valid/mappings/binary.json", stubs[0].File)
+	}
+	mock := Mockery(func() {
+		WireMockStubs(stubs)
+	})
+	assert.Equal(t, "testdata/valid/mappings/binary.json", mock.Endpoints()[0].Location)
+
+	serveBinary := func(version string, body []byte) (int, string) {
+		request := httptest.NewRequest("POST", "http://localhost/binary", bytes.NewReader(body))
+		if version != "" {
+			request.Header.Set("X-Version", version)
+		}
+		w := httptest.NewRecorder()
+		mock.ServeHTTP(w, request)
+		return w.Code, w.Body.String()
+	}
+	status, body := serveBinary("2.0", []byte{0, 1, 2})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "hello", body)
+	status, _ = serveBinary("1.0", []byte{0, 1, 2})
+	assert.Equal(t, 404, status)
+	status, _ = serveBinary("", []byte{0, 1, 2})
+	assert.Equal(t, 404, status)
+	status, _ = serveBinary("2.0", []byte{0, 1})
+	assert.Equal(t, 404, status)
+}
+
+func TestLoadWireMockFixedDelay(t *testing.T) {
+	stubs, err := LoadWireMock("testdata/delay")
+	assert.NoError(t, err)
+	mock := Mockery(func() {
+		WireMockStubs(stubs)
+	})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/delayed", nil))
+	assert.Equal(t, "delayed", w.Body.String())
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "the response took %s", time.Since(start))
+}
+
+func TestLoadWireMockMappings(t *testing.T) {
+	stubs, err := LoadWireMock(".")
+	assert.Nil(t, stubs)
+	assert.EqualError(t, err, "./mappings/testmapping.json: $.fixedDelayInMilliSeconds: unknown or unsupported field")
+}
+
+func TestLoadWireMockErrors(t *testing.T) {
+	stubs, err := LoadWireMock("testdata/strict")
+	assert.Nil(t, stubs)
+	if assert.IsType(t, MappingErrors{}, err) {
+		messages := make([]string, 0)
+		for _, e := range err.(MappingErrors) {
+			messages = append(messages, e.Error())
+		}
+		assert.Contains(t, messages[0], "testdata/strict/mappings/badregex.json: ")
+		assert.Contains(t, messages[0], "/bad[regex")
+		assert.Contains(t, messages[1], "testdata/strict/mappings/badtype.json: ")
+		assert.Contains(t, messages[2], "testdata/strict/mappings/missingfile.json: ")
+		assert.Contains(t, messages[2], "missing.txt")
+		assert.Equal(t, []string{
+			`testdata/strict/mappings/unknown.json: $.persistent: unknown or unsupported field`,
+			`testdata/strict/mappings/unknown.json: $.request.bodyPatterns[0].matchesJsonPath.nope: unknown or unsupported field`,
+			`testdata/strict/mappings/unknown.json: $.request.headers["X-Trace-Id"].containz: unknown or unsupported field`,
+			`testdata/strict/mappings/unknown.json: $.response.statusMessage: unknown or unsupported field`,
+			`testdata/strict/mappings/unknown.json: $.response.transformerParameters: unknown or unsupported field`,
+			`testdata/strict/mappings/unsupported.json: $.request.headers["Content-Type"].equalToJson: equalToJson is only supported in bodyPatterns`,
+			`testdata/strict/mappings/unsupported.json: $.request.queryParameters.doc.matchesXPath: matchesXPath is only supported in bodyPatterns`,
+			`testdata/strict/mappings/unsupported.json: $.response.delayDistribution.type: unsupported delay distribution "chunked", it must be "lognormal" or "uniform"`,
+			`testdata/strict/mappings/unsupported.json: $.response.transformers[1]: unsupported transformer "body-transformer", only "response-template" is supported`,
+			`testdata/strict/mappings/wrongdelay.json: $.fixedDelayMilliseconds: unknown or unsupported field`,
+		}, messages[3:])
+	}
+}
+
+func TestWireMockEndpointsErrors(t *testing.T) {
+	_, err := MockeryE(func() {
+		WireMockEndpoints("testdata/strict")
+		WireMockEndpoints("testdata/none")
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 4) {
+		errs := err.(ConfigErrors)
+		assert.Equal(t, "testdata/strict/mappings/badregex.json", errs[0].Location)
+		assert.Contains(t, errs[0].Message, `invalid urlPattern "/bad[regex"`)
+		assert.Equal(t, "testdata/strict/mappings/badtype.json", errs[1].Location)
+		assert.Contains(t, errs[1].Message, "parsing mapping file: ")
+		assert.Equal(t, "testdata/strict/mappings/missingfile.json", errs[2].Location)
+		assert.Contains(t, errs[2].Message, "reading body file: ")
+		assert.Contains(t, errs[3].Location, "load_test.go:")
+		assert.Contains(t, errs[3].Message, "listing wiremock mapping files: ")
+	}
+}
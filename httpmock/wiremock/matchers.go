@@ -0,0 +1,242 @@
+package wiremock
+
+import (
+	"github.com/bluesoftdev/go-http-matchers/extractor"
+	"github.com/bluesoftdev/go-http-matchers/predicate"
+	"github.com/bluesoftdev/mockery/httpmock"
+	"gopkg.in/xmlpath.v2"
+
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stringPredicate returns a predicate that accepts the strings meeting the equalTo, binaryEqualTo, contains, matches
+// or doesNotMatch condition, or nil if the condition has none of them.  An error is returned if the condition's value
+// is invalid, e.g. a regular expression that doesn't compile.
+func stringPredicate(c *wireMockValueCondition) (predicate.Predicate, error) {
+	caseInsensitive := c.CaseInsensitive != nil && *c.CaseInsensitive
+	switch {
+	case c.BinaryEqualTo != "":
+		expected, err := base64.StdEncoding.DecodeString(c.BinaryEqualTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binaryEqualTo %q: %s", c.BinaryEqualTo, err.Error())
+		}
+		return predicate.StringEquals(string(expected)), nil
+	case c.EqualTo != nil:
+		expected := *c.EqualTo
+		return predicate.PredicateFunc(func(v interface{}) bool {
+			if caseInsensitive {
+				return strings.EqualFold(v.(string), expected)
+			}
+			return v.(string) == expected
+		}), nil
+	case c.Contains != "":
+		return predicate.PredicateFunc(func(v interface{}) bool {
+			if caseInsensitive {
+				return strings.Contains(strings.ToUpper(v.(string)), strings.ToUpper(c.Contains))
+			}
+			return strings.Contains(v.(string), c.Contains)
+		}), nil
+	case c.Matches != "":
+		pattern, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matches %q: %s", c.Matches, err.Error())
+		}
+		return predicate.StringMatches(pattern), nil
+	case c.DoesNotMatch != "":
+		pattern, err := regexp.Compile(c.DoesNotMatch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid doesNotMatch %q: %s", c.DoesNotMatch, err.Error())
+		}
+		return predicate.Not(predicate.StringMatches(pattern)), nil
+	}
+	return nil, nil
+}
+
+// presentValuePredicate returns a predicate for a value that is nil when it is absent, e.g. a cookie.  Absent values
+// are only accepted by the absent condition.
+func presentValuePredicate(c *wireMockValueCondition) (predicate.Predicate, error) {
+	if c.Absent != nil {
+		absent := *c.Absent
+		return predicate.PredicateFunc(func(v interface{}) bool {
+			return (v == nil) == absent
+		}), nil
+	}
+	p, err := stringPredicate(c)
+	if err != nil {
+		return nil, err
+	}
+	return predicate.PredicateFunc(func(v interface{}) bool {
+		return v != nil && (p == nil || p.Accept(v))
+	}), nil
+}
+
+func headerPredicate(name string, c *wireMockValueCondition) (predicate.Predicate, error) {
+	p, err := presentValuePredicate(c)
+	if err != nil {
+		return nil, err
+	}
+	return predicate.ExtractedValueAccepted(extractHeader(name), p), nil
+}
+
+// extractHeader returns an extractor for the first value of the named header, nil is extracted if there is no such
+// header.
+func extractHeader(name string) extractor.Extractor {
+	name = http.CanonicalHeaderKey(name)
+	return extractor.ExtractorFunc(func(r interface{}) interface{} {
+		request := r.(*http.Request)
+		if name == "Host" {
+			return request.Host
+		}
+		if values := request.Header[name]; len(values) > 0 {
+			return values[0]
+		}
+		return nil
+	})
+}
+
+func queryParamPredicate(name string, c *wireMockValueCondition) (predicate.Predicate, error) {
+	p, err := presentValuePredicate(c)
+	if err != nil {
+		return nil, err
+	}
+	return predicate.ExtractedValueAccepted(extractQueryParam(name), p), nil
+}
+
+// extractQueryParam returns an extractor for the first value of the named query parameter, nil is extracted if there
+// is no such parameter.
+func extractQueryParam(name string) extractor.Extractor {
+	return extractor.ExtractorFunc(func(r interface{}) interface{} {
+		if values, ok := r.(*http.Request).URL.Query()[name]; ok && len(values) > 0 {
+			return values[0]
+		}
+		return nil
+	})
+}
+
+func cookiePredicate(name string, c *wireMockValueCondition) (predicate.Predicate, error) {
+	p, err := presentValuePredicate(c)
+	if err != nil {
+		return nil, err
+	}
+	return predicate.ExtractedValueAccepted(extractCookie(name), p), nil
+}
+
+// extractCookie returns an extractor for the value of the named cookie, nil is extracted if there is no such cookie.
+func extractCookie(name string) extractor.Extractor {
+	return extractor.ExtractorFunc(func(r interface{}) interface{} {
+		cookie, err := r.(*http.Request).Cookie(name)
+		if err != nil {
+			return nil
+		}
+		return cookie.Value
+	})
+}
+
+// bodyPredicate returns the predicate for one of the "bodyPatterns" of a mapping, or an error if the pattern is
+// invalid, e.g. JSON that can't be parsed.
+func bodyPredicate(c *wireMockValueCondition) (predicate.Predicate, error) {
+	switch {
+	case c.EqualToJson != nil:
+		expected, ok := c.EqualToJson.(string)
+		if !ok {
+			encoded, err := json.Marshal(c.EqualToJson)
+			if err != nil {
+				return nil, fmt.Errorf("invalid equalToJson: %s", err.Error())
+			}
+			expected = string(encoded)
+		}
+		options := make([]httpmock.JSONOption, 0, 2)
+		if c.IgnoreArrayOrder != nil && *c.IgnoreArrayOrder {
+			options = append(options, httpmock.IgnoreArrayOrder)
+		}
+		if c.IgnoreExtraElements != nil && *c.IgnoreExtraElements {
+			options = append(options, httpmock.IgnoreExtraElements)
+		}
+		p, err := httpmock.BodyEqualsJSONE(expected, options...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid equalToJson: %s", err.Error())
+		}
+		return p, nil
+	case c.MatchesJsonPath != nil:
+		if _, err := httpmock.ExtractJSONPathE(c.MatchesJsonPath.Expression); err != nil {
+			return nil, fmt.Errorf("invalid matchesJsonPath: %s", err.Error())
+		}
+		if c.MatchesJsonPath.Condition == nil {
+			return httpmock.BodyJSONPathExists(c.MatchesJsonPath.Expression), nil
+		}
+		p, err := presentValuePredicate(c.MatchesJsonPath.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return httpmock.BodyJSONPathAccepted(c.MatchesJsonPath.Expression, p), nil
+	case c.EqualToXml != "":
+		p, err := httpmock.BodyEqualsXMLE(c.EqualToXml)
+		if err != nil {
+			return nil, fmt.Errorf("invalid equalToXml: %s", err.Error())
+		}
+		return p, nil
+	case c.MatchesXPath != nil:
+		if _, err := xmlpath.Compile(c.MatchesXPath.Expression); err != nil {
+			return nil, fmt.Errorf("invalid matchesXPath %q: %s", c.MatchesXPath.Expression, err.Error())
+		}
+		if c.MatchesXPath.Condition == nil {
+			return httpmock.BodyXPathExists(c.MatchesXPath.Expression), nil
+		}
+		p, err := presentValuePredicate(c.MatchesXPath.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return httpmock.BodyXPathAccepted(c.MatchesXPath.Expression, p), nil
+	}
+	p, err := stringPredicate(c)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return predicate.ExtractedValueAccepted(httpmock.ExtractBody(), p), nil
+	}
+	return predicate.True(), nil
+}
+
+func basicAuthPredicate(credentials *wireMockBasicAuth) predicate.Predicate {
+	return httpmock.Describe(fmt.Sprintf("basic auth credentials are for %q", credentials.Username),
+		predicate.PredicateFunc(func(r interface{}) bool {
+			username, password, ok := r.(*http.Request).BasicAuth()
+			return ok && username == credentials.Username && password == credentials.Password
+		}))
+}
+
+// describeCondition describes the condition as it is written in the mapping, e.g. `equalTo "abc", caseInsensitive
+// true`, for the diagnostics of unmatched requests.
+func describeCondition(c *wireMockValueCondition) string {
+	parts := make([]string, 0, 2)
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(v.Type().Field(i).Name)
+		name := string(unicode.ToLower(r)) + v.Type().Field(i).Name[size:]
+		if pathCondition, ok := field.Interface().(*wireMockPathCondition); ok {
+			if pathCondition.Condition == nil {
+				parts = append(parts, fmt.Sprintf("%s %q", name, pathCondition.Expression))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s %q with %s", name, pathCondition.Expression,
+					describeCondition(pathCondition.Condition)))
+			}
+			continue
+		}
+		value, _ := json.Marshal(field.Interface())
+		parts = append(parts, name+" "+string(value))
+	}
+	return strings.Join(parts, ", ")
+}
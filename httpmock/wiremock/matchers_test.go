@@ -0,0 +1,84 @@
+package wiremock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/wiremock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var matcherTests = []struct {
+	Name           string
+	Method         string
+	URL            string
+	Header         http.Header
+	Body           string
+	ExpectedStatus int
+	ExpectedBody   string
+}{
+	{"equalToJson", "POST", "/testbody/json", nil, `{"tags": ["b", "a"], "id": 1, "extra": true}`, 200, "json"},
+	{"equalToJson mismatch", "POST", "/testbody/json", nil, `{"tags": ["b", "c"], "id": 1}`, 404, ""},
+	{"matchesJsonPath", "POST", "/testbody/jsonpath", nil, `{"order": {"id": 7, "status": "NEW"}}`, 200, "jsonpath"},
+	{"matchesJsonPath missing", "POST", "/testbody/jsonpath", nil, `{"order": {"status": "NEW"}}`, 404, ""},
+	{"matchesJsonPath mismatch", "POST", "/testbody/jsonpath", nil, `{"order": {"id": 7, "status": "OLD"}}`, 404, ""},
+	{"equalToXml", "POST", "/testbody/xml", nil, "<order status=\"NEW\" id=\"1\">\n  <item>abc</item>\n</order>", 200, "xml"},
+	{"equalToXml mismatch", "POST", "/testbody/xml", nil, `<order status="NEW" id="2"><item>abc</item></order>`, 404, ""},
+	{"matchesXPath", "POST", "/testbody/xpath", nil, `<order><id>1</id><status>BRAND NEW</status></order>`, 200, "xpath"},
+	{"matchesXPath missing", "POST", "/testbody/xpath", nil, `<order><status>NEW</status></order>`, 404, ""},
+	{"string patterns", "POST", "/testbody/string", nil, "well hello there", 200, "string"},
+	{"string patterns mismatch", "POST", "/testbody/string", nil, "hello and goodbye", 404, ""},
+	{"cookies", "GET", "/testcookie", http.Header{"Cookie": {"session=abc"}}, "", 200, "cookie"},
+	{"cookies wrong value", "GET", "/testcookie", http.Header{"Cookie": {"session=xyz"}}, "", 404, ""},
+	{"cookies not absent", "GET", "/testcookie", http.Header{"Cookie": {"session=abc; tracking=1"}}, "", 404, ""},
+	{"basicAuth", "GET", "/testbasicauth", http.Header{"Authorization": {"Basic amVmZjpzZWNyZXQ="}}, "", 200, "basicauth"},
+	{"basicAuth wrong password", "GET", "/testbasicauth", http.Header{"Authorization": {"Basic amVmZjpvb3Bz"}}, "", 404, ""},
+	{"basicAuth missing", "GET", "/testbasicauth", nil, "", 404, ""},
+	{"absent", "GET", "/testabsent", nil, "", 200, "absent"},
+	{"absent header present", "GET", "/testabsent", http.Header{"X-Debug": {""}}, "", 404, ""},
+	{"absent query present", "GET", "/testabsent?debug", nil, "", 404, ""},
+	{"equalTo empty", "GET", "/testempty?filter=", nil, "", 200, "empty"},
+	{"equalTo empty not empty", "GET", "/testempty?filter=open", nil, "", 404, ""},
+	{"equalTo empty missing", "GET", "/testempty", nil, "", 404, ""},
+}
+
+func TestWireMockRequestMatchers(t *testing.T) {
+	mockery := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+	for _, tst := range matcherTests {
+		t.Run(tst.Name, func(t *testing.T) {
+			request := httptest.NewRequest(tst.Method, "http://localhost"+tst.URL, strings.NewReader(tst.Body))
+			for name, values := range tst.Header {
+				request.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+			mockery.ServeHTTP(w, request)
+			assert.Equal(t, tst.ExpectedStatus, w.Code)
+			if tst.ExpectedBody != "" {
+				assert.Equal(t, tst.ExpectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWireMockInvalidRequestMatchers(t *testing.T) {
+	_, err := NewE(func(b *Builder) {
+		AddWireMockEndpoint(b, "__files", "testdata/invalid/matchers.json")
+	})
+	if assert.IsType(t, ConfigErrors{}, err) && assert.Len(t, err, 7) {
+		errs := err.(ConfigErrors)
+		for _, e := range errs {
+			assert.Equal(t, "testdata/invalid/matchers.json", e.Location)
+		}
+		assert.Contains(t, errs[0].Message, `header X-Binary: invalid binaryEqualTo "not base64!"`)
+		assert.Contains(t, errs[1].Message, `header X-Pattern: invalid matches "[a-z"`)
+		assert.Contains(t, errs[2].Message, `query parameter q: invalid doesNotMatch "(open"`)
+		assert.Contains(t, errs[3].Message, "bodyPatterns[0]: invalid equalToJson")
+		assert.Contains(t, errs[4].Message, "bodyPatterns[1]: invalid equalToXml")
+		assert.Contains(t, errs[5].Message, `bodyPatterns[2]: invalid matchesJsonPath: JSONPath "orders[0]" must start with '$'`)
+		assert.Contains(t, errs[6].Message, `bodyPatterns[3]: invalid matchesXPath "//order["`)
+	}
+}
@@ -0,0 +1,170 @@
+package wiremock
+
+import (
+	"github.com/bluesoftdev/mockery/httpmock"
+	"github.com/bluesoftdev/mockery/httpmock/internal/uuid"
+
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Recorder is an httpmock.ProxyRecorder that writes the exchanges forwarded by httpmock.RecordingProxyTo as wiremock
+// mappings, which can be loaded back with WireMockEndpoints, e.g.
+//
+//    recorder, err := wiremock.NewRecorder("testdata/upstream")
+//    ...
+//    mock := httpmock.Mockery(func() {
+//      httpmock.EndpointPattern(".*", func() {
+//        httpmock.RecordingProxyTo("https://upstream.example.com", recorder)
+//      })
+//    })
+//
+// Each distinct request, by method, URL and body, is recorded once, the first response received for it is kept.  The
+// mapping of a request with a body matches the body with "equalToJson" if it is JSON, "equalTo" if it is other text
+// and "binaryEqualTo" otherwise.
+type Recorder struct {
+	dirName  string
+	lock     sync.Mutex
+	recorded map[string]bool
+}
+
+// skippedHeaders are the response headers that are not written to the recorded mappings.
+var skippedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Date":              true,
+	"Transfer-Encoding": true,
+}
+
+var unsafeFileNameChars = regexp.MustCompile("[^A-Za-z0-9._-]+")
+
+// NewRecorder returns a Recorder that writes the mappings to the "mappings" subdirectory of dirName and the response
+// bodies to the "__files" subdirectory, the directories are created if they don't exist.
+func NewRecorder(dirName string) (*Recorder, error) {
+	for _, subDir := range []string{"mappings", "__files"} {
+		if err := os.MkdirAll(filepath.Join(dirName, subDir), 0755); err != nil {
+			return nil, fmt.Errorf("creating recording directory: %s", err.Error())
+		}
+	}
+	return &Recorder{dirName: dirName, recorded: make(map[string]bool)}, nil
+}
+
+// Record writes the exchange as a wiremock mapping unless the same request has already been recorded.
+func (r *Recorder) Record(exchange *httpmock.ProxyExchange) {
+	key := fmt.Sprintf("%s %s %x", exchange.Request.Method, exchange.Request.URL.RequestURI(),
+		sha1.Sum(exchange.RequestBody))
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.recorded[key] {
+		return
+	}
+	if err := r.write(exchange); err != nil {
+		log.Printf("ERROR while recording %s %s: %+v", exchange.Request.Method, exchange.Request.URL, err)
+		return
+	}
+	r.recorded[key] = true
+}
+
+func (r *Recorder) write(exchange *httpmock.ProxyExchange) error {
+	id := uuid.New()
+	baseName := strings.ToLower(exchange.Request.Method) + "-" +
+		strings.Trim(unsafeFileNameChars.ReplaceAllString(exchange.Request.URL.Path, "-"), "-") + "-" + id[:8]
+	mapping := recordedMapping{
+		ID:   id,
+		Name: exchange.Request.Method + " " + exchange.Request.URL.RequestURI(),
+		Request: recordedRequest{
+			Method:       exchange.Request.Method,
+			URL:          exchange.Request.URL.RequestURI(),
+			BodyPatterns: recordedBodyPatterns(exchange),
+		},
+		Response: recordedResponse{
+			Status:  exchange.Status,
+			Headers: make(map[string]interface{}),
+		},
+	}
+	for name, values := range exchange.Header {
+		if skippedHeaders[name] {
+			continue
+		}
+		if len(values) == 1 {
+			mapping.Response.Headers[name] = values[0]
+		} else {
+			mapping.Response.Headers[name] = values
+		}
+	}
+	if len(exchange.Body) > 0 {
+		mapping.Response.BodyFileName = baseName + bodyFileExtension(exchange.Header.Get("Content-Type"))
+		bodyFile := filepath.Join(r.dirName, "__files", mapping.Response.BodyFileName)
+		if err := ioutil.WriteFile(bodyFile, exchange.Body, 0644); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(&mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(r.dirName, "mappings", baseName+".json"), data, 0644)
+}
+
+// recordedBodyPatterns returns the "bodyPatterns" matching the body of the exchange's request, none if it has no body.
+func recordedBodyPatterns(exchange *httpmock.ProxyExchange) []recordedBodyPattern {
+	body := exchange.RequestBody
+	switch {
+	case len(body) == 0:
+		return nil
+	case strings.Contains(exchange.Request.Header.Get("Content-Type"), "json") && json.Valid(body):
+		return []recordedBodyPattern{{EqualToJson: json.RawMessage(body)}}
+	case utf8.Valid(body):
+		return []recordedBodyPattern{{EqualTo: string(body)}}
+	}
+	return []recordedBodyPattern{{BinaryEqualTo: base64.StdEncoding.EncodeToString(body)}}
+}
+
+type recordedBodyPattern struct {
+	EqualTo       string          `json:"equalTo,omitempty"`
+	EqualToJson   json.RawMessage `json:"equalToJson,omitempty"`
+	BinaryEqualTo string          `json:"binaryEqualTo,omitempty"`
+}
+
+type recordedRequest struct {
+	Method       string                `json:"method"`
+	URL          string                `json:"url"`
+	BodyPatterns []recordedBodyPattern `json:"bodyPatterns,omitempty"`
+}
+
+type recordedResponse struct {
+	Status       int                    `json:"status"`
+	Headers      map[string]interface{} `json:"headers,omitempty"`
+	BodyFileName string                 `json:"bodyFileName,omitempty"`
+}
+
+type recordedMapping struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+// bodyFileExtension returns the extension of the file a response body with the content type given is written to.
+func bodyFileExtension(contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return ".json"
+	case strings.Contains(contentType, "xml"):
+		return ".xml"
+	case strings.Contains(contentType, "html"):
+		return ".html"
+	case strings.HasPrefix(contentType, "text/"):
+		return ".txt"
+	}
+	return ".bin"
+}
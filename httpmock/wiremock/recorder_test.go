@@ -0,0 +1,150 @@
+package wiremock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/wiremock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndPlayback(t *testing.T) {
+	upstreamCalls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		upstreamCalls++
+		switch request.URL.Path {
+		case "/orders/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("Set-Cookie", "a=1")
+			w.Header().Add("Set-Cookie", "b=2")
+			w.Write([]byte(`{"id": 1}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "recording")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recorder, err := NewRecorder(dir)
+	assert.NoError(t, err)
+	proxy := Mockery(func() {
+		EndpointPattern(".*", func() {
+			RecordingProxyTo(upstream.URL, recorder)
+		})
+	})
+	for _, url := range []string{"/orders/1", "/orders/1", "/ship?order=1"} {
+		proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost"+url, nil))
+	}
+	assert.Equal(t, 3, upstreamCalls)
+	mappings, _ := filepath.Glob(filepath.Join(dir, "mappings", "*.json"))
+	assert.Len(t, mappings, 2)
+
+	upstream.Close()
+	playback := Mockery(func() {
+		WireMockEndpoints(dir)
+	})
+
+	w := httptest.NewRecorder()
+	playback.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/orders/1", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"id": 1}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"a=1", "b=2"}, w.Header()["Set-Cookie"])
+
+	w = httptest.NewRecorder()
+	playback.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/ship?order=1", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	playback.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/ship?order=2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRecordRequestBodies(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		w.Write([]byte("created " + string(body)))
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "recording")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recorder, err := NewRecorder(dir)
+	assert.NoError(t, err)
+	proxy := Mockery(func() {
+		EndpointPattern(".*", func() {
+			RecordingProxyTo(upstream.URL, recorder)
+		})
+	})
+	requests := []struct{ contentType, body string }{
+		{"application/json", `{"name": "a"}`},
+		{"application/json", `{"name": "b"}`},
+		{"text/plain", "c"},
+		{"application/octet-stream", "\xff\xfe"},
+	}
+	for _, r := range requests {
+		request := httptest.NewRequest("POST", "http://localhost/orders", strings.NewReader(r.body))
+		request.Header.Set("Content-Type", r.contentType)
+		proxy.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	mappings, _ := filepath.Glob(filepath.Join(dir, "mappings", "*.json"))
+	assert.Len(t, mappings, 4)
+
+	upstream.Close()
+	playback := Mockery(func() {
+		WireMockEndpoints(dir)
+	})
+	for _, r := range requests {
+		w := httptest.NewRecorder()
+		playback.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost/orders", strings.NewReader(r.body)))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "created "+r.body, w.Body.String())
+	}
+	w := httptest.NewRecorder()
+	playback.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost/orders", strings.NewReader(`{"name": "d"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNewRecorderError(t *testing.T) {
+	file, err := ioutil.TempFile("", "recording")
+	assert.NoError(t, err)
+	file.Close()
+	defer os.Remove(file.Name())
+
+	recorder, err := NewRecorder(filepath.Join(file.Name(), "upstream"))
+	assert.Nil(t, recorder)
+	assert.Error(t, err)
+}
+
+func TestWireMockProxyBaseUrl(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		w.Write([]byte("proxied " + request.URL.Path))
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "proxymapping")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	mappingFile := filepath.Join(dir, "proxy.json")
+	mapping := `{"request": {"urlPathPattern": "/proxied/.*"}, "response": {"proxyBaseUrl": "` + upstream.URL + `"}}`
+	assert.NoError(t, ioutil.WriteFile(mappingFile, []byte(mapping), 0644))
+
+	mock := Mockery(func() {
+		WireMockEndpoint(dir, mappingFile)
+	})
+
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/proxied/thing", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "proxied /proxied/thing", w.Body.String())
+}
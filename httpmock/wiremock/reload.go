@@ -0,0 +1,140 @@
+package wiremock
+
+import (
+	"github.com/bluesoftdev/mockery/httpmock"
+
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReloadOption modifies how ReloadWireMockEndpoints and WatchWireMockEndpoints load the changed mappings.
+type ReloadOption int
+
+const (
+	// StrictMappings loads the mappings with LoadWireMock, so that a reload fails, and the endpoints are left as they
+	// were, if a mapping has fields or values that are not supported.
+	StrictMappings ReloadOption = iota
+)
+
+// ReloadWireMockEndpoints replaces the endpoints of the mock that were loaded from the mapping files in dirName, by
+// WireMockEndpoints or an earlier reload, with the endpoints defined by the files now in dirName.  The endpoints are
+// swapped atomically, requests in flight complete with the endpoints they started with.  If the mappings can't be
+// loaded an error is returned and the endpoints are left as they were.  Endpoints added through the admin API are
+// kept.  A Reset of the mock restores the reloaded endpoints rather than those first loaded.  With StrictMappings the
+// mappings are checked as LoadWireMock checks them.
+func ReloadWireMockEndpoints(mock httpmock.Mock, dirName string, options ...ReloadOption) error {
+	strict := false
+	for _, option := range options {
+		if option == StrictMappings {
+			strict = true
+		}
+	}
+	var stubs []Stub
+	if strict {
+		var err error
+		if stubs, err = LoadWireMock(dirName); err != nil {
+			return err
+		}
+	}
+	mappingDir := filepath.Join(dirName, "mappings")
+	ids := make([]string, 0)
+	for _, e := range mock.Endpoints() {
+		if filepath.Dir(filepath.Clean(e.Location)) == mappingDir {
+			ids = append(ids, e.ID)
+		}
+	}
+	_, err := mock.ReplaceEndpoints(ids, func(b *httpmock.Builder) {
+		if strict {
+			AddStubs(b, stubs)
+		} else {
+			AddWireMockEndpoints(b, dirName)
+		}
+	})
+	return err
+}
+
+// Watcher reloads the wiremock endpoints of a mock whenever the files they were loaded from change, see
+// WatchWireMockEndpoints.
+type Watcher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchWireMockEndpoints checks the "mappings" and "__files" subdirectories of dirName for changes every interval
+// and reloads the mock's endpoints with ReloadWireMockEndpoints when a file is added, removed or modified, e.g.
+//
+//    mock := Mockery(func() {
+//      wiremock.WireMockEndpoints("./stubs")
+//    })
+//    watcher := wiremock.WatchWireMockEndpoints(mock, "./stubs", time.Second)
+//    defer watcher.Stop()
+//
+// Errors loading the changed mappings are logged and the previous endpoints are kept.  The options are passed to
+// ReloadWireMockEndpoints, e.g. StrictMappings for mappings that were loaded with LoadWireMock.
+func WatchWireMockEndpoints(mock httpmock.Mock, dirName string, interval time.Duration,
+	options ...ReloadOption) *Watcher {
+	w := &Watcher{stop: make(chan struct{}), done: make(chan struct{})}
+	last := snapshotFiles(dirName)
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+			current := snapshotFiles(dirName)
+			if sameFiles(last, current) {
+				continue
+			}
+			last = current
+			if err := ReloadWireMockEndpoints(mock, dirName, options...); err != nil {
+				log.Printf("ERROR while reloading wiremock endpoints from %s: %+v", dirName, err)
+			} else {
+				log.Printf("Reloaded wiremock endpoints from %s", dirName)
+			}
+		}
+	}()
+	return w
+}
+
+// Stop stops watching for changes, it returns once the watcher has stopped.
+func (w *Watcher) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotFiles returns the state of the files under the mappings and __files subdirectories of dirName.
+func snapshotFiles(dirName string) map[string]fileState {
+	files := make(map[string]fileState)
+	for _, subDir := range []string{"mappings", "__files"} {
+		filepath.Walk(filepath.Join(dirName, subDir), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func sameFiles(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(state.modTime) || other.size != state.size {
+			return false
+		}
+	}
+	return true
+}
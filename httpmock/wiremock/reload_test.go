@@ -0,0 +1,130 @@
+package wiremock_test
+
+import (
+	. "github.com/bluesoftdev/mockery/httpmock"
+	. "github.com/bluesoftdev/mockery/httpmock/wiremock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeMapping(t *testing.T, dir, name, mapping string) {
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mappings", name), []byte(mapping), 0644))
+}
+
+func get(mock Mock, url string) (int, string) {
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	return w.Code, w.Body.String()
+}
+
+func newStubDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "stubs")
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "mappings"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "__files"), 0755))
+	writeMapping(t, dir, "hello.json", `{"request": {"url": "/hello"}, "response": {"status": 200, "body": "hello"}}`)
+	return dir
+}
+
+func TestReloadWireMockEndpoints(t *testing.T) {
+	dir := newStubDir(t)
+	defer os.RemoveAll(dir)
+	mock := Mockery(func() {
+		WireMockEndpoints(dir)
+		Endpoint("/other", func() {
+			Method("GET", func() {
+				RespondWithString(200, "other")
+			})
+		})
+	})
+	mock.AddEndpoints(func(b *Builder) {
+		b.DefinedAt(AdminLocation, func() {
+			b.EndpointPattern("/added", func() {
+				b.RespondWithString(200, "added")
+			})
+		})
+	})
+	_, body := get(mock, "http://localhost/hello")
+	assert.Equal(t, "hello", body)
+
+	writeMapping(t, dir, "hello.json", `{"request": {"url": "/hello"}, "response": {"status": 200, "body": "bonjour"}}`)
+	writeMapping(t, dir, "bye.json", `{"request": {"url": "/bye"}, "response": {"status": 200, "body": "bye"}}`)
+	assert.NoError(t, ReloadWireMockEndpoints(mock, dir))
+
+	_, body = get(mock, "http://localhost/hello")
+	assert.Equal(t, "bonjour", body)
+	_, body = get(mock, "http://localhost/bye")
+	assert.Equal(t, "bye", body)
+	_, body = get(mock, "http://localhost/other")
+	assert.Equal(t, "other", body)
+	_, body = get(mock, "http://localhost/added")
+	assert.Equal(t, "added", body)
+	assert.Len(t, mock.Endpoints(), 4)
+
+	writeMapping(t, dir, "bye.json", `{"request": {"url": "/bye"}`)
+	assert.Error(t, ReloadWireMockEndpoints(mock, dir))
+	_, body = get(mock, "http://localhost/bye")
+	assert.Equal(t, "bye", body)
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "mappings", "bye.json")))
+	assert.NoError(t, ReloadWireMockEndpoints(mock, dir+string(os.PathSeparator)))
+	status, _ := get(mock, "http://localhost/bye")
+	assert.Equal(t, 404, status)
+	assert.Len(t, mock.Endpoints(), 3)
+
+	mock.Reset()
+	_, body = get(mock, "http://localhost/hello")
+	assert.Equal(t, "bonjour", body)
+	status, _ = get(mock, "http://localhost/added")
+	assert.Equal(t, 404, status)
+	assert.Len(t, mock.Endpoints(), 2)
+}
+
+func TestWatchWireMockEndpoints(t *testing.T) {
+	dir := newStubDir(t)
+	defer os.RemoveAll(dir)
+	mock := Mockery(func() {
+		WireMockEndpoints(dir)
+	})
+	watcher := WatchWireMockEndpoints(mock, dir, 10*time.Millisecond)
+	defer watcher.Stop()
+
+	writeMapping(t, dir, "hello.json", `{"request": {"url": "/hello"}, "response": {"status": 200, "body": "hello, again"}}`)
+	deadline := time.Now().Add(5 * time.Second)
+	var body string
+	for time.Now().Before(deadline) {
+		if _, body = get(mock, "http://localhost/hello"); body == "hello, again" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, "hello, again", body)
+}
+
+func TestReloadWireMockEndpointsStrict(t *testing.T) {
+	dir := newStubDir(t)
+	defer os.RemoveAll(dir)
+	stubs, err := LoadWireMock(dir)
+	assert.NoError(t, err)
+	mock := Mockery(func() {
+		WireMockStubs(stubs)
+	})
+
+	writeMapping(t, dir, "hello.json",
+		`{"request": {"url": "/hello"}, "response": {"status": 200, "body": "bonjour", "statusMessage": "OK"}}`)
+	err = ReloadWireMockEndpoints(mock, dir, StrictMappings)
+	if assert.IsType(t, MappingErrors{}, err) {
+		assert.Contains(t, err.Error(), "$.response.statusMessage: unknown or unsupported field")
+	}
+	_, body := get(mock, "http://localhost/hello")
+	assert.Equal(t, "hello", body)
+
+	assert.NoError(t, ReloadWireMockEndpoints(mock, dir))
+	_, body = get(mock, "http://localhost/hello")
+	assert.Equal(t, "bonjour", body)
+}
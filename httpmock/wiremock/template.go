@@ -0,0 +1,153 @@
+package wiremock
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var handlebarsExpression = regexp.MustCompile(`\{\{\{?(.*?)\}?\}\}`)
+
+// translateTemplate converts a wiremock "response-template" (handlebars) into a text/template as used by
+// httpmock.RespondWithTemplate.  Only the commonly used helpers are supported, an error is returned for any expression
+// that can't be translated.
+func translateTemplate(text string) (string, error) {
+	var translateErr error
+	translated := handlebarsExpression.ReplaceAllStringFunc(text, func(expression string) string {
+		inner := handlebarsExpression.FindStringSubmatch(expression)[1]
+		result, err := translateExpression(strings.TrimSpace(inner))
+		if err != nil && translateErr == nil {
+			translateErr = err
+		}
+		return "{{" + result + "}}"
+	})
+	return translated, translateErr
+}
+
+func translateExpression(expression string) (string, error) {
+	tokens := splitExpression(expression)
+	if len(tokens) == 0 {
+		return "", fmt.Errorf("empty template expression")
+	}
+	switch tokens[0] {
+	case "now":
+		if len(tokens) > 1 {
+			return "", fmt.Errorf("unsupported template expression {{%s}}, now does not accept arguments", expression)
+		}
+		return "now", nil
+	case "randomValue":
+		length := "16"
+		kind := "ALPHANUMERIC"
+		for _, arg := range tokens[1:] {
+			name, value := splitHashArgument(arg)
+			switch name {
+			case "length":
+				length = value
+			case "type":
+				kind = strings.ToUpper(value)
+			default:
+				return "", fmt.Errorf("unsupported randomValue argument %q in {{%s}}", arg, expression)
+			}
+		}
+		if kind == "UUID" {
+			return "uuid", nil
+		}
+		if _, err := strconv.Atoi(length); err != nil {
+			return "", fmt.Errorf("invalid randomValue length in {{%s}}", expression)
+		}
+		return fmt.Sprintf("randomValue %s %q", length, kind), nil
+	case "jsonPath", "xPath":
+		if len(tokens) != 3 || tokens[1] != "request.body" {
+			return "", fmt.Errorf("unsupported template expression {{%s}}", expression)
+		}
+		method := "JSONPath"
+		if tokens[0] == "xPath" {
+			method = "XPath"
+		}
+		return fmt.Sprintf(".Request.%s %q", method, unquote(tokens[2])), nil
+	}
+	if len(tokens) > 1 || !strings.HasPrefix(tokens[0], "request.") {
+		return "", fmt.Errorf("unsupported template expression {{%s}}", expression)
+	}
+	return translateRequestReference(expression, tokens[0])
+}
+
+// translateRequestReference translates the request model references, e.g. request.path.[1] or request.headers.Accept.
+func translateRequestReference(expression, reference string) (string, error) {
+	parts := strings.Split(strings.Replace(reference, "request.requestLine.", "request.", 1), ".")[1:]
+	for i, part := range parts {
+		parts[i] = strings.TrimSuffix(strings.TrimPrefix(part, "["), "]")
+	}
+	if len(parts) == 1 {
+		switch parts[0] {
+		case "url":
+			return ".Request.URL", nil
+		case "path":
+			return ".Request.Path", nil
+		case "method":
+			return ".Request.Method", nil
+		case "body":
+			return ".Request.Body", nil
+		}
+	} else if parts[0] == "path" || parts[0] == "pathSegments" {
+		if index, err := strconv.Atoi(parts[1]); err == nil && len(parts) == 2 {
+			return fmt.Sprintf(".Request.PathSegment %d", index), nil
+		}
+	} else if len(parts) == 2 || (len(parts) == 3 && parts[2] == "0") {
+		switch parts[0] {
+		case "query":
+			return fmt.Sprintf(".Request.QueryParam %q", parts[1]), nil
+		case "headers":
+			return fmt.Sprintf(".Request.Header %q", parts[1]), nil
+		case "cookies":
+			return fmt.Sprintf(".Request.Cookie %q", parts[1]), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported template expression {{%s}}", expression)
+}
+
+// splitExpression splits a handlebars expression on white space, quoted strings are kept together.
+func splitExpression(expression string) []string {
+	tokens := make([]string, 0, 3)
+	var current strings.Builder
+	var quote rune
+	for _, c := range expression {
+		switch {
+		case quote != 0:
+			current.WriteRune(c)
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			current.WriteRune(c)
+		case c == ' ' || c == '\t' || c == '\n':
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func splitHashArgument(arg string) (string, string) {
+	i := strings.Index(arg, "=")
+	if i < 0 {
+		return arg, ""
+	}
+	return arg[:i], unquote(arg[i+1:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package wiremock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var templateTranslations = []struct {
+	Handlebars string
+	Template   string
+}{
+	{"{{request.url}}", "{{.Request.URL}}"},
+	{"{{request.path}}", "{{.Request.Path}}"},
+	{"{{request.method}}", "{{.Request.Method}}"},
+	{"{{{request.body}}}", "{{.Request.Body}}"},
+	{"{{request.path.[2]}}", "{{.Request.PathSegment 2}}"},
+	{"{{request.requestLine.pathSegments.[0]}}", "{{.Request.PathSegment 0}}"},
+	{"{{request.query.id}}", `{{.Request.QueryParam "id"}}`},
+	{"{{request.query.id.[0]}}", `{{.Request.QueryParam "id"}}`},
+	{"{{request.headers.X-Request-Id}}", `{{.Request.Header "X-Request-Id"}}`},
+	{"{{request.headers.[X-Request-Id]}}", `{{.Request.Header "X-Request-Id"}}`},
+	{"{{request.cookies.session}}", `{{.Request.Cookie "session"}}`},
+	{"{{jsonPath request.body '$.order.id'}}", `{{.Request.JSONPath "$.order.id"}}`},
+	{"{{xPath request.body '/order/id/text()'}}", `{{.Request.XPath "/order/id/text()"}}`},
+	{"{{now}}", "{{now}}"},
+	{"{{randomValue length=10 type='ALPHABETIC'}}", `{{randomValue 10 "ALPHABETIC"}}`},
+	{"{{randomValue type='UUID'}}", "{{uuid}}"},
+	{`{"id": "{{request.path.[1]}}"}`, `{"id": "{{.Request.PathSegment 1}}"}`},
+}
+
+func TestTranslateTemplate(t *testing.T) {
+	for _, tst := range templateTranslations {
+		t.Run(tst.Handlebars, func(t *testing.T) {
+			translated, err := translateTemplate(tst.Handlebars)
+			assert.NoError(t, err)
+			assert.Equal(t, tst.Template, translated)
+		})
+	}
+}
+
+func TestTranslateTemplateUnsupported(t *testing.T) {
+	for _, handlebars := range []string{
+		"{{now format='yyyy-MM-dd'}}",
+		"{{#each request.headers}}{{/each}}",
+		"{{request.query}}",
+		"{{randomValue length=abc}}",
+	} {
+		_, err := translateTemplate(handlebars)
+		assert.Error(t, err, handlebars)
+	}
+}
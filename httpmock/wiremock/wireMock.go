@@ -4,46 +4,92 @@ import (
 	"github.com/bluesoftdev/go-http-matchers/predicate"
 	"github.com/bluesoftdev/mockery/httpmock"
 
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"math"
+	"net/http"
 	"os"
 	"regexp"
-	"time"
+	"sort"
 )
 
 type wireMockValueCondition struct {
-	EqualTo         string
+	EqualTo         *string
 	CaseInsensitive *bool
 	BinaryEqualTo   string
 	Contains        string
 	Matches         string
 	DoesNotMatch    string
+	Absent          *bool
+
+	EqualToJson         interface{}
+	IgnoreArrayOrder    *bool
+	IgnoreExtraElements *bool
+	MatchesJsonPath     *wireMockPathCondition
+	EqualToXml          string
+	MatchesXPath        *wireMockPathCondition
+}
+
+// wireMockPathCondition is the value of "matchesJsonPath" or "matchesXPath", either an expression that must select
+// something or an object with the expression and a condition the selected value must meet.
+type wireMockPathCondition struct {
+	Expression string
+	Condition  *wireMockValueCondition
+}
+
+func (c *wireMockPathCondition) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Expression); err == nil {
+		return nil
+	}
+	var condition struct {
+		Expression string
+		wireMockValueCondition
+	}
+	if err := json.Unmarshal(data, &condition); err != nil {
+		return err
+	}
+	c.Expression = condition.Expression
+	c.Condition = &condition.wireMockValueCondition
+	return nil
+}
+
+type wireMockBasicAuth struct {
+	Username string
+	Password string
 }
 
 type wireMockRequest struct {
-	Method          string
-	Url             string `json:"url"`
-	UrlPattern      string `json:"urlPattern"`
-	UrlPath         string `json:"urlPath"`
-	UrlPathPattern  string `json:"urlPathPattern"`
-	Headers         map[string]wireMockValueCondition
-	QueryParameters map[string]wireMockValueCondition
+	Method               string
+	Url                  string `json:"url"`
+	UrlPattern           string `json:"urlPattern"`
+	UrlPath              string `json:"urlPath"`
+	UrlPathPattern       string `json:"urlPathPattern"`
+	Headers              map[string]wireMockValueCondition
+	QueryParameters      map[string]wireMockValueCondition
+	Cookies              map[string]wireMockValueCondition
+	BodyPatterns         []wireMockValueCondition
+	BasicAuthCredentials *wireMockBasicAuth
 }
 
 type wireMockDelayDistribution struct {
 	Algorithm string `json:"type"`
 	Median    int
 	Sigma     float64
+	MaxValue  int
 	Lower     int
 	Upper     int
 }
 
+type wireMockChunkedDribbleDelay struct {
+	NumberOfChunks int
+	TotalDuration  int
+}
+
 type wireMockResponse struct {
-	Status        int
-	StatusMessage string
-	Headers       map[string]interface{}
+	Status  int
+	Headers map[string]interface{}
 
 	Body         string
 	JsonBody     interface{}
@@ -52,12 +98,34 @@ type wireMockResponse struct {
 
 	FixedDelayMilliseconds *int
 	DelayDistribution      *wireMockDelayDistribution
+	ChunkedDribbleDelay    *wireMockChunkedDribbleDelay
+
+	ProxyBaseUrl string
+	Fault        string
+
+	Transformers []string
+}
+
+// templated returns true if the response uses the "response-template" transformer.
+func (r *wireMockResponse) templated() bool {
+	for _, transformer := range r.Transformers {
+		if transformer == "response-template" {
+			return true
+		}
+	}
+	return false
 }
 
 type wireMock struct {
-	Priority *int
-	Request  wireMockRequest
-	Response wireMockResponse
+	Id                    string
+	Uuid                  string
+	Name                  string
+	Priority              *int
+	Request               wireMockRequest
+	Response              wireMockResponse
+	ScenarioName          string
+	RequiredScenarioState string
+	NewScenarioState      string
 }
 
 var mappingFilePattern = regexp.MustCompile("^.*\\.json$")
@@ -65,15 +133,21 @@ var mappingFilePattern = regexp.MustCompile("^.*\\.json$")
 // WireMockEndpoints takes the dirName and looks for .json files in a subdirectory named "mappings"
 // any files named in the mappings are looked for in the __files subdirectory of the base dir name.
 func WireMockEndpoints(dirName string) {
+	AddWireMockEndpoints(httpmock.CurrentBuilder(), dirName)
+}
+
+// AddWireMockEndpoints is like WireMockEndpoints but adds the endpoints to the given builder.
+func AddWireMockEndpoints(b *httpmock.Builder, dirName string) {
 	mappingDir := dirName + string(os.PathSeparator) + "mappings"
 	dataDir := dirName + string(os.PathSeparator) + "__files"
 	mappingFiles, err := ioutil.ReadDir(mappingDir)
 	if err != nil {
-		panic("Error trying to list mapping files:" + err.Error())
+		b.Errorf("listing wiremock mapping files: %s", err.Error())
+		return
 	}
 	for _, mappingFile := range mappingFiles {
 		if mappingFilePattern.MatchString(mappingFile.Name()) {
-			WireMockEndpoint(dataDir, mappingDir+"/"+mappingFile.Name())
+			AddWireMockEndpoint(b, dataDir, mappingDir+"/"+mappingFile.Name())
 		}
 	}
 }
@@ -81,108 +155,296 @@ func WireMockEndpoints(dirName string) {
 // WireMockEndpoint takes the name of the base dir the files are expected in and the filename of a
 // wiremock .json mapping file.
 func WireMockEndpoint(dataDirName, fileName string) {
-	f, err := os.Open(fileName)
+	AddWireMockEndpoint(httpmock.CurrentBuilder(), dataDirName, fileName)
+}
+
+// AddWireMockEndpoint is like WireMockEndpoint but adds the endpoint to the given builder.
+func AddWireMockEndpoint(b *httpmock.Builder, dataDirName, fileName string) {
+	b.DefinedAt(fileName, func() {
+		f, err := os.Open(fileName)
+		if err != nil {
+			b.Errorf("opening mapping file: %s", err.Error())
+			return
+		}
+		defer f.Close()
+		wm, err := parseWireMock(f)
+		if err != nil {
+			b.Errorf("parsing mapping file: %s", err.Error())
+			return
+		}
+		addWireMock(b, dataDirName, wm)
+	})
+}
+
+// checkWireMock defines the endpoint for the mapping with a throw away builder and returns the errors found, so that
+// LoadWireMock can report them before the stubs are added to a mock.
+func checkWireMock(dataDirName string, wm *wireMock) httpmock.ConfigErrors {
+	_, err := httpmock.NewE(func(b *httpmock.Builder) {
+		addWireMock(b, dataDirName, wm)
+	})
 	if err != nil {
-		panic("Error opening mapping file: " + err.Error())
+		return err.(httpmock.ConfigErrors)
+	}
+	return nil
+}
+
+func sortedConditionKeys(conditions map[string]wireMockValueCondition) []string {
+	keys := make([]string, 0, len(conditions))
+	for key := range conditions {
+		keys = append(keys, key)
 	}
-	d := json.NewDecoder(f)
+	sort.Strings(keys)
+	return keys
+}
+
+func parseWireMock(r io.Reader) (*wireMock, error) {
+	d := json.NewDecoder(r)
 	var wm wireMock
-	err = d.Decode(&wm)
-	if err != nil {
-		panic("Error parsing mapping file: " + err.Error())
+	if err := d.Decode(&wm); err != nil {
+		return nil, err
 	}
+	return &wm, nil
+}
+
+// addWireMock defines the endpoint for the mapping, files named in the mapping are looked for in the dataDirName.
+func addWireMock(b *httpmock.Builder, dataDirName string, wm *wireMock) {
 	predicates := make([]predicate.Predicate, 0, 10)
 	if wm.Request.Url != "" {
-		predicates = append(predicates, predicate.RequestURIEquals(wm.Request.Url))
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("url is %q", wm.Request.Url),
+			predicate.RequestURIEquals(wm.Request.Url)))
 	} else if wm.Request.UrlPattern != "" {
-		predicates = append(predicates, predicate.RequestURIMatches(regexp.MustCompile(wm.Request.UrlPattern)))
+		pattern, err := regexp.Compile(wm.Request.UrlPattern)
+		if err != nil {
+			b.Errorf("invalid urlPattern %q: %s", wm.Request.UrlPattern, err.Error())
+			return
+		}
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("url matches %q", wm.Request.UrlPattern),
+			predicate.RequestURIMatches(pattern)))
 	} else if wm.Request.UrlPath != "" {
-		predicates = append(predicates, predicate.PathEquals(wm.Request.UrlPath))
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("path is %q", wm.Request.UrlPath),
+			predicate.PathEquals(wm.Request.UrlPath)))
 	} else if wm.Request.UrlPathPattern != "" {
-		predicates = append(predicates, predicate.PathMatches(regexp.MustCompile(wm.Request.UrlPathPattern)))
+		pattern, err := regexp.Compile(wm.Request.UrlPathPattern)
+		if err != nil {
+			b.Errorf("invalid urlPathPattern %q: %s", wm.Request.UrlPathPattern, err.Error())
+			return
+		}
+		predicates = append(predicates, httpmock.Describe(fmt.Sprintf("path matches %q", wm.Request.UrlPathPattern),
+			predicate.PathMatches(pattern)))
 	}
 	if wm.Request.Method != "" {
-		predicates = append(predicates, predicate.MethodIs(wm.Request.Method))
-	}
-	if wm.Request.Headers != nil {
-		for header, condition := range wm.Request.Headers {
-			if condition.EqualTo != "" {
-				if condition.CaseInsensitive != nil && *condition.CaseInsensitive {
-					predicates = append(predicates, predicate.HeaderEqualsIgnoreCase(header, condition.EqualTo))
-				} else {
-					predicates = append(predicates, predicate.HeaderEquals(header, condition.EqualTo))
-				}
-			} else if condition.Contains != "" {
-				if condition.CaseInsensitive != nil && *condition.CaseInsensitive {
-					predicates = append(predicates, predicate.HeaderContainsIgnoreCase(header, condition.Contains))
-				} else {
-					predicates = append(predicates, predicate.HeaderContains(header, condition.Contains))
-				}
-			} else if condition.Matches != "" {
-				predicates = append(predicates, predicate.HeaderMatches(header, regexp.MustCompile(condition.Matches)))
-			} else if condition.DoesNotMatch != "" {
-				predicates = append(predicates, predicate.Not(predicate.HeaderMatches(header, regexp.MustCompile(condition.Matches))))
-			}
+		predicates = append(predicates, httpmock.Describe("method is "+wm.Request.Method,
+			predicate.MethodIs(wm.Request.Method)))
+	}
+	valid := true
+	for _, name := range sortedConditionKeys(wm.Request.Headers) {
+		condition := wm.Request.Headers[name]
+		p, err := headerPredicate(name, &condition)
+		if err != nil {
+			b.Errorf("header %s: %s", name, err.Error())
+			valid = false
+			continue
 		}
+		predicates = append(predicates, httpmock.Describe(
+			fmt.Sprintf("header %s %s", name, describeCondition(&condition)), p))
 	}
-	if wm.Request.QueryParameters != nil {
-		for query, condition := range wm.Request.QueryParameters {
-			if condition.EqualTo != "" {
-				if condition.CaseInsensitive != nil && *condition.CaseInsensitive {
-					predicates = append(predicates, predicate.QueryParamEqualsIgnoreCase(query, condition.EqualTo))
-				} else {
-					predicates = append(predicates, predicate.QueryParamEquals(query, condition.EqualTo))
-				}
-			} else if condition.Contains != "" {
-				if condition.CaseInsensitive != nil && *condition.CaseInsensitive {
-					predicates = append(predicates, predicate.QueryParamContainsIgnoreCase(query, condition.Contains))
-				} else {
-					predicates = append(predicates, predicate.QueryParamContains(query, condition.Contains))
-				}
-			} else if condition.Matches != "" {
-				predicates = append(predicates, predicate.QueryParamMatches(query, regexp.MustCompile(condition.Matches)))
-			} else if condition.DoesNotMatch != "" {
-				predicates = append(predicates, predicate.Not(predicate.QueryParamMatches(query, regexp.MustCompile(condition.Matches))))
-			}
+	for _, name := range sortedConditionKeys(wm.Request.QueryParameters) {
+		condition := wm.Request.QueryParameters[name]
+		p, err := queryParamPredicate(name, &condition)
+		if err != nil {
+			b.Errorf("query parameter %s: %s", name, err.Error())
+			valid = false
+			continue
+		}
+		predicates = append(predicates, httpmock.Describe(
+			fmt.Sprintf("query parameter %s %s", name, describeCondition(&condition)), p))
+	}
+	for _, name := range sortedConditionKeys(wm.Request.Cookies) {
+		condition := wm.Request.Cookies[name]
+		p, err := cookiePredicate(name, &condition)
+		if err != nil {
+			b.Errorf("cookie %s: %s", name, err.Error())
+			valid = false
+			continue
+		}
+		predicates = append(predicates, httpmock.Describe(
+			fmt.Sprintf("cookie %s %s", name, describeCondition(&condition)), p))
+	}
+	for i := range wm.Request.BodyPatterns {
+		condition := &wm.Request.BodyPatterns[i]
+		p, err := bodyPredicate(condition)
+		if err != nil {
+			b.Errorf("bodyPatterns[%d]: %s", i, err.Error())
+			valid = false
+			continue
 		}
+		predicates = append(predicates, httpmock.Describe("body "+describeCondition(condition), p))
+	}
+	if !valid {
+		return
+	}
+	if wm.Request.BasicAuthCredentials != nil {
+		predicates = append(predicates, basicAuthPredicate(wm.Request.BasicAuthCredentials))
 	}
 	priority := httpmock.DefaultPriority
 	if wm.Priority != nil {
 		priority = *wm.Priority
 	}
-	httpmock.EndpointForConditionWithPriority(priority, predicate.And(predicates...), func() {
-		if wm.Response.Headers != nil {
-			for k, v := range wm.Response.Headers {
-				httpmock.Header(k, v.(string))
+	endpoint := func() {
+		b.EndpointForConditionWithPriority(priority, httpmock.AllOf(predicates...), func() {
+			wireMockResponseConfig(b, dataDirName, wm)
+		})
+	}
+	if wm.ScenarioName == "" {
+		endpoint()
+		return
+	}
+	b.Scenario(wm.ScenarioName, func() {
+		if wm.RequiredScenarioState != "" {
+			b.InState(wm.RequiredScenarioState, endpoint)
+		} else {
+			endpoint()
+		}
+	})
+}
+
+func wireMockResponseConfig(b *httpmock.Builder, dataDirName string, wm *wireMock) {
+	if wm.Response.Fault != "" {
+		b.Fault(httpmock.FaultType(wm.Response.Fault))
+	} else if wm.Response.ProxyBaseUrl != "" {
+		b.ProxyTo(wm.Response.ProxyBaseUrl)
+	} else if wm.Response.templated() {
+		wireMockTemplatedResponseConfig(b, dataDirName, wm)
+	} else {
+		wireMockStaticResponseConfig(b, dataDirName, wm)
+	}
+	wireMockDelayConfig(b, wm)
+	if wm.NewScenarioState != "" {
+		b.TransitionTo(wm.NewScenarioState)
+	}
+}
+
+func wireMockStaticResponseConfig(b *httpmock.Builder, dataDirName string, wm *wireMock) {
+	if wm.Response.Headers != nil {
+		for k, v := range wm.Response.Headers {
+			name, values := k, headerValues(v)
+			b.DecorateHandlerBefore(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+				for _, value := range values {
+					w.Header().Add(name, value)
+				}
+			}))
+		}
+	}
+	if wm.Response.BodyFileName != "" {
+		b.LogLocation(fmt.Sprintf("Responding with %s", wm.Response.BodyFileName))
+		b.RespondWithFile(wm.Response.Status, dataDirName+"/"+wm.Response.BodyFileName)
+	} else if wm.Response.Body != "" {
+		b.RespondWithString(wm.Response.Status, wm.Response.Body)
+	} else if wm.Response.JsonBody != nil {
+		b.RespondWithJson(wm.Response.Status, wm.Response.JsonBody)
+	} else if wm.Response.Base64Body != "" {
+		if body, ok := decodeBase64Body(b, wm); ok {
+			b.WriteStatusAndBody(wm.Response.Status, body)
+		}
+	} else {
+		b.Respond(wm.Response.Status)
+	}
+}
+
+func wireMockTemplatedResponseConfig(b *httpmock.Builder, dataDirName string, wm *wireMock) {
+	respondWithTemplate := func(text string) {
+		translated, err := translateTemplate(text)
+		if err != nil {
+			b.Errorf("translating response template: %s", err.Error())
+			return
+		}
+		b.RespondWithTemplate(wm.Response.Status, translated)
+	}
+	if wm.Response.Headers != nil {
+		names := make([]string, 0, len(wm.Response.Headers))
+		for name := range wm.Response.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, value := range headerValues(wm.Response.Headers[name]) {
+				translated, err := translateTemplate(value)
+				if err != nil {
+					b.Errorf("translating template for header %s: %s", name, err.Error())
+					continue
+				}
+				b.HeaderTemplate(name, translated)
 			}
 		}
-		if wm.Response.BodyFileName != "" {
-			httpmock.LogLocation(fmt.Sprintf("Responding with %s", wm.Response.BodyFileName))
-			httpmock.RespondWithFile(wm.Response.Status, dataDirName+"/"+wm.Response.BodyFileName)
-		} else if wm.Response.Body != "" {
-			httpmock.RespondWithString(wm.Response.Status, wm.Response.Body)
-		} else if wm.Response.JsonBody != nil {
-			httpmock.RespondWithJson(wm.Response.Status, wm.Response.JsonBody)
-		} else {
-			httpmock.Respond(wm.Response.Status)
-		}
-		if wm.Response.DelayDistribution != nil {
-			if wm.Response.DelayDistribution.Algorithm == "lognormal" {
-				s := wm.Response.DelayDistribution.Sigma
-				mean := float64(wm.Response.DelayDistribution.Median) * float64(time.Millisecond) / float64(time.Second)
-				u := math.Log(mean)
-				stddev := math.Sqrt(math.Exp(2*u+s*s) * (math.Exp(s*s) - 1))
-				httpmock.NormalDelay(
-					fmt.Sprintf("%dns", int(mean*float64(time.Second))),
-					fmt.Sprintf("%dns", int(stddev*float64(time.Second))),
-					fmt.Sprintf("%dns", wm.Response.DelayDistribution.Median+int(stddev*5.0*float64(time.Second))))
-			} else if wm.Response.DelayDistribution.Algorithm == "uniform" {
-				httpmock.UniformDelay(
-					fmt.Sprintf("%dms", wm.Response.DelayDistribution.Lower),
-					fmt.Sprintf("%dms", wm.Response.DelayDistribution.Upper))
+	}
+	if wm.Response.BodyFileName != "" {
+		body, err := ioutil.ReadFile(dataDirName + "/" + wm.Response.BodyFileName)
+		if err != nil {
+			b.Errorf("reading body file: %s", err.Error())
+			return
+		}
+		respondWithTemplate(string(body))
+	} else if wm.Response.Body != "" {
+		respondWithTemplate(wm.Response.Body)
+	} else if wm.Response.JsonBody != nil {
+		body, err := json.Marshal(wm.Response.JsonBody)
+		if err != nil {
+			b.Errorf("encoding jsonBody: %s", err.Error())
+			return
+		}
+		b.Header("Content-Type", "application/json")
+		respondWithTemplate(string(body))
+	} else if wm.Response.Base64Body != "" {
+		if body, ok := decodeBase64Body(b, wm); ok {
+			respondWithTemplate(string(body))
+		}
+	} else {
+		b.Respond(wm.Response.Status)
+	}
+}
+
+func decodeBase64Body(b *httpmock.Builder, wm *wireMock) ([]byte, bool) {
+	body, err := base64.StdEncoding.DecodeString(wm.Response.Base64Body)
+	if err != nil {
+		b.Errorf("decoding base64Body: %s", err.Error())
+		return nil, false
+	}
+	return body, true
+}
+
+// headerValues returns the values of a response header, which may be given as a string or an array of strings.
+func headerValues(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, len(value))
+		for i, item := range value {
+			values[i] = fmt.Sprintf("%v", item)
+		}
+		return values
+	}
+	return []string{fmt.Sprintf("%v", v)}
+}
+
+func wireMockDelayConfig(b *httpmock.Builder, wm *wireMock) {
+	if wm.Response.DelayDistribution != nil {
+		if wm.Response.DelayDistribution.Algorithm == "lognormal" {
+			max := ""
+			if wm.Response.DelayDistribution.MaxValue > 0 {
+				max = fmt.Sprintf("%dms", wm.Response.DelayDistribution.MaxValue)
 			}
-		} else if wm.Response.FixedDelayMilliseconds != nil {
-			httpmock.FixedDelay(fmt.Sprintf("%dms", *wm.Response.FixedDelayMilliseconds))
+			b.LogNormalDelay(fmt.Sprintf("%dms", wm.Response.DelayDistribution.Median),
+				wm.Response.DelayDistribution.Sigma, max)
+		} else if wm.Response.DelayDistribution.Algorithm == "uniform" {
+			b.UniformDelay(
+				fmt.Sprintf("%dms", wm.Response.DelayDistribution.Lower),
+				fmt.Sprintf("%dms", wm.Response.DelayDistribution.Upper))
 		}
-	})
+	} else if wm.Response.FixedDelayMilliseconds != nil {
+		b.FixedDelay(fmt.Sprintf("%dms", *wm.Response.FixedDelayMilliseconds))
+	}
+	if dribble := wm.Response.ChunkedDribbleDelay; dribble != nil {
+		b.ChunkedDribbleDelay(dribble.NumberOfChunks, fmt.Sprintf("%dms", dribble.TotalDuration))
+	}
 }
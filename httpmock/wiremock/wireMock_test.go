@@ -6,8 +6,11 @@ import (
 	. "github.com/bluesoftdev/mockery/httpmock/wiremock"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestWireMockEndpoints(t *testing.T) {
@@ -141,3 +144,136 @@ func TestWireMockEndpointsHeaderMatching(t *testing.T) {
 
 	assert.Equal(t, 200, response.StatusCode)
 }
+
+func TestAddWireMockEndpoints(t *testing.T) {
+	mockery := New(func(b *Builder) {
+		AddWireMockEndpoints(b, ".")
+	})
+
+	testRequest := httptest.NewRequest("GET", "http://localhost/testmapping", nil)
+	responseWriter := httptest.NewRecorder()
+	mockery.ServeHTTP(responseWriter, testRequest)
+	response := responseWriter.Result()
+	assert.Equal(t, 200, response.StatusCode)
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("default test mapping"), body)
+}
+
+func TestWireMockEndpointsScenario(t *testing.T) {
+	mockery := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+
+	get := func() string {
+		responseWriter := httptest.NewRecorder()
+		mockery.ServeHTTP(responseWriter, httptest.NewRequest("GET", "http://localhost/testscenario/order", nil))
+		assert.Equal(t, 200, responseWriter.Code)
+		return responseWriter.Body.String()
+	}
+
+	assert.Equal(t, "PENDING", get())
+	assert.Equal(t, "PENDING", get())
+
+	responseWriter := httptest.NewRecorder()
+	mockery.ServeHTTP(responseWriter, httptest.NewRequest("POST", "http://localhost/testscenario/ship", nil))
+	assert.Equal(t, 204, responseWriter.Code)
+
+	assert.Equal(t, "SHIPPED", get())
+
+	mockery.ResetScenarios()
+	assert.Equal(t, "PENDING", get())
+}
+
+func TestWireMockEndpointsResponseTemplate(t *testing.T) {
+	mockery := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+
+	testRequest := httptest.NewRequest("POST", "http://localhost/testtemplate/1234?q=foo", strings.NewReader(`{"name": "joe"}`))
+	testRequest.Header.Set("X-Request-Id", "abcd")
+	responseWriter := httptest.NewRecorder()
+	mockery.ServeHTTP(responseWriter, testRequest)
+	response := responseWriter.Result()
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "text/plain", response.Header.Get("Content-Type"))
+	assert.Equal(t, "abcd", response.Header.Get("X-Request-Id"))
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^id=1234 q=foo name=joe ref=[0-9]{8}$`, string(body))
+}
+
+func TestWireMockEndpointsDiagnostics(t *testing.T) {
+	mockery := Mockery(func() {
+		DiagnoseUnmatched()
+		WireMockEndpoints("testdata/valid")
+	})
+
+	testRequest := httptest.NewRequest("POST", "http://localhost/binary", strings.NewReader("abc"))
+	testRequest.Header.Set("X-Version", "1.2")
+	responseWriter := httptest.NewRecorder()
+	mockery.ServeHTTP(responseWriter, testRequest)
+	assert.Equal(t, 404, responseWriter.Code)
+	assert.Equal(t, `No endpoint matched POST /binary
+Closest endpoints:
+  testdata/valid/mappings/binary.json
+    failed: header X-Version doesNotMatch "^1\\..*"
+    failed: body binaryEqualTo "AAEC"
+`, responseWriter.Body.String())
+}
+
+func TestWireMockEndpointsFault(t *testing.T) {
+	mockery := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+	server := httptest.NewServer(mockery)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/testfault")
+	if !assert.Error(t, err) {
+		response.Body.Close()
+	}
+
+	_, err = NewE(func(b *Builder) {
+		AddWireMockEndpoint(b, "__files", "testdata/fault/unknown.json")
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, `unknown fault "CONNECTION_LOST"`, err.(ConfigErrors)[0].Message)
+	}
+}
+
+func TestWireMockEndpointsChunkedDribbleDelay(t *testing.T) {
+	mockery := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+	server := httptest.NewServer(mockery)
+	defer server.Close()
+
+	start := time.Now()
+	response, err := http.Get(server.URL + "/testdribble")
+	if assert.NoError(t, err) {
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "dribbled body", string(body))
+	}
+	assert.True(t, time.Since(start) >= 120*time.Millisecond, "the body took %s", time.Since(start))
+}
+
+func TestWireMockEndpointsLogNormalDelay(t *testing.T) {
+	mockery := Mockery(func() {
+		WireMockEndpoints(".")
+	})
+
+	var total time.Duration
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		mockery.ServeHTTP(w, httptest.NewRequest("GET", "/testlognormal", nil))
+		elapsed := time.Since(start)
+		total += elapsed
+		assert.Equal(t, "log-normal", w.Body.String())
+		assert.True(t, elapsed < 80*time.Millisecond, "the response took %s", elapsed)
+	}
+	assert.True(t, total/20 >= 20*time.Millisecond, "the mean delay was %s", total/20)
+}